@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"effective-mobile-go/internal/config"
+)
+
+// runConfig prints the effective settings as KEY=value with their sources, the secrets
+// redacted. The config errors are reported after the settings.
+//
+//	Usage: app [-config FILE] [-set KEY=value]... config print
+func runConfig(_ config.Config, args []string) error {
+
+	fs := flag.NewFlagSet("config", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: [-config FILE] [-set KEY=value]... config print")
+	}
+	fs.Parse(args)
+
+	if fs.Arg(0) != "print" {
+		fs.Usage()
+		return fmt.Errorf("unknown config command %q", fs.Arg(0))
+	}
+
+	_, settings, err := config.LoadSettings(configOpts)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, s := range settings {
+		s = s.Redacted()
+		fmt.Fprintf(w, "%s=%s\t# %s\n", s.Key, s.Value, s.Source)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return err
+}
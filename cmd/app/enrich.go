@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"effective-mobile-go/internal/config"
+	"effective-mobile-go/internal/model"
+)
+
+// runEnrich looks the songs matching the filters up in the music info API again and fills
+// their missing details, and writes the per-song report as NDJSON to stdout.
+//
+//	Usage: app enrich [-overwrite] [-dry-run] [-concurrency N] [filters]
+func runEnrich(cfg config.Config, args []string) error {
+
+	fs := flag.NewFlagSet("enrich", flag.ExitOnError)
+	overwrite := fs.Bool("overwrite", false, "replace the present details too, not only the missing ones")
+	dryRun := fs.Bool("dry-run", false, "report the changes only, do not write anything")
+	concurrency := fs.Int("concurrency", 0, "max songs processed at once")
+	filters := songFilterFlags(fs)
+	fs.Parse(args)
+
+	db, err := openDB(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	service, _ := newService(cfg, db, newRemoteRepo(cfg.RemoteAPI))
+
+	results, err := service.EnrichSongs(ctx, filters(), model.EnrichOptions{
+		DryRun:      *dryRun,
+		Overwrite:   *overwrite,
+		Concurrency: *concurrency,
+	})
+
+	// the results are written even if interrupted: the songs before are already updated
+	counts := map[model.EnrichStatus]int{}
+	enc := json.NewEncoder(os.Stdout)
+
+	for _, v := range results {
+		counts[v.Status]++
+		enc.Encode(&v)
+	}
+
+	if err != nil {
+		return fmt.Errorf("enrich interrupted, %d songs reported: %w", len(results), err)
+	}
+
+	slog.Info("enrich done", "dryRun", *dryRun, "overwrite", *overwrite,
+		"updated", counts[model.EnrichUpdated],
+		"changed", counts[model.EnrichChanged],
+		"unchanged", counts[model.EnrichUnchanged],
+		"failed", counts[model.EnrichFailed],
+	)
+
+	if n := counts[model.EnrichFailed]; n > 0 {
+		return fmt.Errorf("%d songs failed", n)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"effective-mobile-go/internal/config"
+	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/songio"
+)
+
+// runExport writes the songs matching the filters to the file (or stdout if the file is
+// "-"). The columns match the import ones, so the file can be imported back.
+//
+//	Usage: app export [-format csv|json|ndjson|xlsx] [-with-text] [-o FILE] [filters]
+func runExport(cfg config.Config, args []string) error {
+
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	formatName := fs.String("format", "csv", "file format: csv, json, ndjson or xlsx")
+	withText := fs.Bool("with-text", false, "include song text")
+	fileName := fs.String("o", "-", "output file")
+	filters := songFilterFlags(fs)
+	fs.Parse(args)
+
+	format, err := songio.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	file := os.Stdout
+	if *fileName != "-" {
+		file, err = os.Create(*fileName)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+	}
+
+	dest, err := songio.NewWriter(format, file, *withText)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	var n int
+
+	service, _ := newService(cfg, db, newRemoteRepo(cfg.RemoteAPI))
+
+	err = service.ExportSongs(ctx, filters(), *withText, func(song model.SongDetail) error {
+		n++
+		return dest.Write(song)
+	})
+
+	if err == nil {
+		err = dest.Close()
+	}
+
+	if err == nil && file != os.Stdout {
+		err = file.Close()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	slog.Info("export done", "songs", n, "format", format, "file", *fileName)
+	return nil
+}
+
+// songFilterFlags defines the song filter flags of the command. The returned function
+// returns the filters after the flags are parsed.
+func songFilterFlags(fs *flag.FlagSet) func() model.SongFilters {
+
+	var (
+		release model.Date
+		limit   uint64
+	)
+
+	group := fs.String("group", "", "song group name")
+	name := fs.String("song", "", "song name")
+	text := fs.String("text", "", "song text should contain it")
+	lang := fs.String("lang", "", "detected song text language")
+	fs.Func("release", "song release date (DD.MM.YYYY)", func(s string) (err error) {
+		release, err = model.ParseDate(s)
+		return err
+	})
+	fs.Uint64Var(&limit, "limit", 0, "max number of songs (0 is no limit)")
+
+	return func() model.SongFilters {
+
+		var filters model.SongFilters
+
+		set := map[string]bool{}
+		fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+		if set["group"] {
+			filters.Group = group
+		}
+		if set["song"] {
+			filters.Name = name
+		}
+		if set["text"] {
+			filters.Text = text
+		}
+		if set["lang"] {
+			filters.Lang = lang
+		}
+		if set["release"] {
+			filters.Release = &release
+		}
+		if limit > 0 {
+			filters.Limit = &limit
+		}
+
+		return filters
+	}
+}
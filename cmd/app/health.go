@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"effective-mobile-go/internal/config"
+	"effective-mobile-go/internal/health"
+)
+
+// newHealthChecker returns the readiness checks: the DB is up and, if schemaVersion is not
+// zero, has that schema version, and (if enabled) the remote API answers.
+func newHealthChecker(cfg config.Health, db *sql.DB, remote remoteRepo, schemaVersion int64) *health.Checker {
+
+	checker := health.New(cfg.Timeout)
+
+	checker.Add("db", db.PingContext, 0)
+
+	if schemaVersion != 0 {
+		checker.Add("migrations", func(ctx context.Context) error {
+			return checkSchema(ctx, db, schemaVersion)
+		}, 0)
+	}
+
+	if cfg.RemoteTTL > 0 {
+		checker.Add("remoteAPI", remote.Ping, cfg.RemoteTTL)
+	}
+
+	return checker
+}
+
+// runHealthcheck requests the readiness (or the liveness) probe of the running server
+// and fails unless it responds 200. It's for the container health check, where there
+// may be no curl.
+//
+//	Usage: app healthcheck [-live] [-url URL] [-timeout D]
+func runHealthcheck(cfg config.Config, args []string) error {
+
+	fs := flag.NewFlagSet("healthcheck", flag.ExitOnError)
+	live := fs.Bool("live", false, "request the liveness probe instead of the readiness one")
+	baseURL := fs.String("url", fmt.Sprintf("http://localhost:%d", cfg.Server.Port), "server URL")
+	timeout := fs.Duration("timeout", 5*time.Second, "request timeout")
+	fs.Parse(args)
+
+	path := "/readyz"
+	if *live {
+		path = "/healthz"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(*baseURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	os.Stdout.Write(body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded %s", path, resp.Status)
+	}
+
+	return nil
+}
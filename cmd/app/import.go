@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"effective-mobile-go/internal/config"
+	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/songio"
+)
+
+// runImport imports songs from the file (or stdin if the file is "-") and writes the
+// per-row report as NDJSON to stdout.
+//
+//	Usage: app import [-format csv|json|ndjson] [-dry-run] [-concurrency N] FILE
+func runImport(cfg config.Config, args []string) error {
+
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	formatName := fs.String("format", "", "file format: csv, json or ndjson (by default, by the file extension)")
+	dryRun := fs.Bool("dry-run", false, "validate only, do not write anything")
+	concurrency := fs.Int("concurrency", 0, "max rows processed at once")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("file is required")
+	}
+
+	fileName := fs.Arg(0)
+
+	var (
+		format songio.Format
+		err    error
+	)
+
+	if *formatName != "" {
+		format, err = songio.ParseFormat(*formatName)
+	} else {
+		format, err = songio.FormatFromFileName(fileName)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	file := os.Stdin
+	if fileName != "-" {
+		file, err = os.Open(fileName)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+	}
+
+	reader, err := songio.NewReader(format, file)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	service, _ := newService(cfg, db, newRemoteRepo(cfg.RemoteAPI))
+
+	results, err := service.ImportSongs(ctx, reader, model.ImportOptions{
+		DryRun:      *dryRun,
+		Concurrency: *concurrency,
+	})
+
+	// the results are written even if interrupted: the rows before are already stored
+	counts := map[model.ImportStatus]int{}
+	enc := json.NewEncoder(os.Stdout)
+
+	for _, v := range results {
+		counts[v.Status]++
+		enc.Encode(&v)
+	}
+
+	if err != nil {
+		return fmt.Errorf("import interrupted, %d rows reported: %w", len(results), err)
+	}
+
+	slog.Info("import done", "dryRun", *dryRun,
+		"created", counts[model.ImportCreated],
+		"existing", counts[model.ImportExisting],
+		"valid", counts[model.ImportValid],
+		"failed", counts[model.ImportFailed],
+	)
+
+	if n := counts[model.ImportFailed]; n > 0 {
+		return fmt.Errorf("%d rows failed", n)
+	}
+
+	return nil
+}
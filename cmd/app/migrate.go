@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/pressly/goose/v3"
+
+	"effective-mobile-go/internal/config"
+	"effective-mobile-go/migrations"
+)
+
+// migrationsDir is the directory of the migrations in migrations.FS.
+const migrationsDir = "."
+
+func init() {
+	goose.SetBaseFS(migrations.FS)
+}
+
+// runMigrate applies or rolls back the migrations. Without -to, up applies all pending
+// migrations and down rolls back the last one.
+//
+//	Usage: app migrate {up|down|status|redo|version} [-to VERSION]
+func runMigrate(cfg config.Config, args []string) error {
+
+	var cmd string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: migrate {up|down|status|redo|version} [-to VERSION]")
+		fs.PrintDefaults()
+	}
+	to := fs.Int64("to", -1, "target version (up and down only)")
+	fs.Parse(args)
+
+	if !slices.Contains([]string{"up", "down", "status", "redo", "version"}, cmd) {
+		fs.Usage()
+		return fmt.Errorf("unknown migrate command %q", cmd)
+	}
+
+	if *to >= 0 && cmd != "up" && cmd != "down" {
+		return errors.New("-to is for up and down only")
+	}
+
+	db, err := openDB(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	switch {
+	case cmd == "up" && *to >= 0:
+		return goose.UpToContext(ctx, db, migrationsDir, *to)
+	case cmd == "up":
+		return goose.UpContext(ctx, db, migrationsDir)
+	case cmd == "down" && *to >= 0:
+		return goose.DownToContext(ctx, db, migrationsDir, *to)
+	case cmd == "down":
+		return goose.DownContext(ctx, db, migrationsDir)
+	case cmd == "status":
+		return goose.StatusContext(ctx, db, migrationsDir)
+	case cmd == "redo":
+		return goose.RedoContext(ctx, db, migrationsDir)
+	default:
+		return goose.VersionContext(ctx, db, migrationsDir)
+	}
+}
+
+// lastMigration returns the version of the last migration of the binary.
+func lastMigration() (int64, error) {
+
+	list, err := goose.CollectMigrations(migrationsDir, 0, goose.MaxVersion)
+	if err != nil {
+		return 0, err
+	}
+
+	last, err := list.Last()
+	if err != nil {
+		return 0, err
+	}
+
+	return last.Version, nil
+}
+
+// checkSchema returns an error if the version of the DB schema isn't the one of the last
+// migration of the binary.
+func checkSchema(ctx context.Context, db *sql.DB, want int64) error {
+
+	v, err := goose.GetDBVersionContext(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case v < want:
+		return fmt.Errorf("schema version %d is older than %d of the binary, run `migrate up`", v, want)
+	case v > want:
+		return fmt.Errorf("schema version %d is newer than %d of the binary, upgrade the binary or run `migrate down -to %d`", v, want, want)
+	}
+
+	return nil
+}
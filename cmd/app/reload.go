@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/joho/godotenv"
+
+	"effective-mobile-go/internal/config"
+)
+
+// reloader reloads the config (the config file and .env are read again) on SIGHUP or the
+// admin request and applies the settings which can be changed at runtime (see
+// config.Reloaded). The changes of the others are logged as ignored. If the reloaded config
+// is invalid, nothing is applied.
+type reloader struct {
+	mu       sync.Mutex // one reload at a time
+	cfg      atomic.Pointer[config.Config]
+	settings []config.Setting
+	apply    []func(old, cfg config.Config)
+}
+
+func newReloader(cfg config.Config, settings []config.Setting) *reloader {
+	r := &reloader{settings: settings}
+	r.cfg.Store(&cfg)
+	return r
+}
+
+// OnReload adds the function applying the reloaded config. It's called on every reload,
+// old is the config before it.
+func (r *reloader) OnReload(fn func(old, cfg config.Config)) {
+	r.apply = append(r.apply, fn)
+}
+
+// Config returns the config in effect.
+func (r *reloader) Config() config.Config {
+	return *r.cfg.Load()
+}
+
+func (r *reloader) Reload() ([]config.Change, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := loadDotenv(); err != nil {
+		return nil, fmt.Errorf("config is not reloaded: %w", err)
+	}
+
+	loaded, loadedSettings, err := config.LoadSettings(configOpts)
+	if err != nil {
+		return nil, fmt.Errorf("config is not reloaded: %w", err)
+	}
+
+	old := r.Config()
+	cfg, settings, changes := config.Reloaded(old, r.settings, loaded, loadedSettings)
+
+	for _, fn := range r.apply {
+		fn(old, cfg)
+	}
+
+	r.cfg.Store(&cfg)
+	r.settings = settings
+
+	for _, c := range changes {
+		if c.Applied {
+			slog.Info("setting changed", "key", c.Key, "old", c.Old, "new", c.New)
+		} else {
+			slog.Warn("setting change ignored, restart needed", "key", c.Key, "old", c.Old, "new", c.New)
+		}
+	}
+
+	slog.Info("config reloaded", "changes", len(changes))
+	return changes, nil
+}
+
+// dotenvKeys are the env variables set from .env by loadDotenv.
+var dotenvKeys = map[string]bool{}
+
+// loadDotenv sets the env variables of .env, if it exists. The variables of the process
+// environment are not overridden, so they (and the -set flags) stay as they were on
+// startup. On the next call the changes of the file are applied: the variables removed
+// from it are unset.
+func loadDotenv() error {
+
+	vars, err := godotenv.Read()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("can't read .env: %w", err)
+	}
+
+	for key := range dotenvKeys {
+		if _, ok := vars[key]; !ok {
+			os.Unsetenv(key)
+			delete(dotenvKeys, key)
+		}
+	}
+
+	for key, value := range vars {
+		if _, ok := os.LookupEnv(key); ok && !dotenvKeys[key] {
+			continue // the process environment
+		}
+		os.Setenv(key, value)
+		dotenvKeys[key] = true
+	}
+
+	return nil
+}
+
+// watch reloads the config on every SIGHUP.
+func (r *reloader) watch() {
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+
+	for range c {
+		slog.Info("signal was received", slog.Any("signal", syscall.SIGHUP))
+		if _, err := r.Reload(); err != nil {
+			slog.Error("can't reload config", "error", err)
+		}
+	}
+}
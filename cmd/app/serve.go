@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	httpSwagger "github.com/swaggo/http-swagger/v2"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"effective-mobile-go/docs"
+	"effective-mobile-go/internal/admin"
+	"effective-mobile-go/internal/auth"
+	"effective-mobile-go/internal/config"
+	"effective-mobile-go/internal/handler"
+	"effective-mobile-go/internal/metrics"
+	"effective-mobile-go/internal/middleware"
+	"effective-mobile-go/internal/openapi"
+	"effective-mobile-go/internal/repo/localrepo"
+	"effective-mobile-go/internal/tracing"
+)
+
+// runServe runs the API and the admin servers until SIGINT or SIGTERM. The config is
+// reloaded on SIGHUP or POST /reload of the admin server.
+//
+//	Usage: app serve [-migrate=false] [-check-schema=false]
+func runServe(cfg config.Config, args []string) error {
+
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	migrate := fs.Bool("migrate", true, "apply the pending migrations on startup")
+	checkSchemaVersion := fs.Bool("check-schema", true, "refuse to start unless the DB schema version is the one of the binary")
+	fs.Parse(args)
+
+	db, err := openDB(cfg.DB)
+	if err != nil {
+		return fmt.Errorf("can't open db: %w", err)
+	}
+	defer db.Close()
+
+	if *migrate {
+		if err := goose.Up(db, migrationsDir); err != nil {
+			return fmt.Errorf("can't up migrations: %w", err)
+		}
+	}
+
+	var schemaVersion int64 // not checked if zero
+
+	if *checkSchemaVersion {
+		schemaVersion, err = lastMigration()
+		if err != nil {
+			return fmt.Errorf("can't read migrations: %w", err)
+		}
+		if err := checkSchema(context.Background(), db, schemaVersion); err != nil {
+			return err
+		}
+	}
+
+	remoteRepo := newRemoteRepo(cfg.RemoteAPI)
+	service, setCacheTTL := newService(cfg, db, remoteRepo)
+
+	checker := newHealthChecker(cfg.Health, db, remoteRepo, schemaVersion)
+
+	authn, err := auth.New(localrepo.New(db, config.Cache{}), cfg.Auth)
+	if err != nil {
+		return fmt.Errorf("can't setup auth: %w", err)
+	}
+
+	// setup router
+	router := http.NewServeMux()
+	docs.SwaggerInfo.Host = fmt.Sprintf("localhost:%d", cfg.Server.Port)
+	router.Handle("/swagger/", httpSwagger.Handler(httpSwagger.URL("http://"+docs.SwaggerInfo.Host+"/swagger/doc.json")))
+
+	var limiter handler.Limiter
+	var rateLimiter *middleware.RateLimiter
+	if cfg.RateLimit.Enabled {
+		rateLimiter = newRateLimiter(cfg.RateLimit, db)
+		limiter = rateLimiter
+	}
+
+	api := http.StripPrefix("/api/v1", handler.New(service, limiter))
+
+	if cfg.OpenAPI.Validate {
+		validator, err := openapi.New([]byte(docs.SwaggerInfo.ReadDoc()))
+		if err != nil {
+			return fmt.Errorf("can't load openapi spec: %w", err)
+		}
+		api = validator.Handler(api, cfg.OpenAPI.ValidateResponses)
+	}
+	router.Handle("/api/v1/", middleware.Auth(api, authn, cfg.Auth))
+	router.Handle("GET /api/v1/ping", api) // public
+	router.Handle("GET /healthz", checker.Liveness())
+	router.Handle("GET /readyz", checker.Readiness())
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("can't setup tracing: %w", err)
+	}
+
+	server := setupHTTPServer(otelhttp.NewHandler(middleware.Logging(middleware.Recovery(router)), "http.server"), cfg.Server)
+
+	// setup config reload
+	rl := newReloader(cfg, configSettings)
+
+	rl.OnReload(func(old, cfg config.Config) {
+		if cfg.Logger.PlainText != old.Logger.PlainText {
+			slog.SetDefault(newLogger(cfg.Logger, &logLevel))
+		}
+		if cfg.Logger.Level != old.Logger.Level {
+			logLevel.Set(cfg.Logger.Level)
+		}
+		remoteRepo.SetConfig(cfg.RemoteAPI)
+		setCacheTTL(cfg.Cache)
+		if rateLimiter != nil {
+			rateLimiter.SetRates(cfg.RateLimit)
+		}
+		checker.SetTimeout(cfg.Health.Timeout)
+	})
+
+	go rl.watch()
+
+	// setup admin server
+	metrics.RegisterDB(db)
+
+	adminCfg := cfg.Server
+	adminCfg.Port = cfg.Admin.Port
+	adminServer := setupHTTPServer(admin.Restrict(admin.New(rl, &logLevel), cfg.Admin.Token), adminCfg)
+	adminServer.WriteTimeout = 0 // profiles and traces take as long as asked
+
+	adminListener, err := listenAdmin(cfg.Admin)
+	if err != nil {
+		return fmt.Errorf("can't listen admin: %w", err)
+	}
+
+	go func() {
+		slog.Info("admin server startup", "addr", adminListener.Addr())
+		if err := adminServer.Serve(adminListener); err != http.ErrServerClosed {
+			logFatal("admin server failed", err)
+		}
+	}()
+
+	// setup graceful shutdown
+	go func() {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+		sig := <-c
+		slog.Info("signal was received", slog.Any("signal", sig))
+
+		// let the load balancer see that we're not ready before the listener is closed
+		cfg := rl.Config()
+
+		checker.ShutDown()
+		time.Sleep(cfg.Health.ShutdownDelay)
+
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+		server.Shutdown(ctx)
+		adminServer.Shutdown(ctx)
+	}()
+
+	slog.Info("server startup", "addr", server.Addr)
+	slog.Debug("server startup", "server", fmt.Sprintf("%+v", server))
+
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return fmt.Errorf("server failed: %w", err)
+	}
+
+	slog.Info("server stopped")
+
+	ctx, cancel := context.WithTimeout(context.Background(), rl.Config().Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("can't flush traces", "error", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"effective-mobile-go/internal/config"
+)
+
+// runStats computes the text statistics of the songs matching the filters which have none,
+// e.g. of the songs created before the statistics was introduced.
+//
+//	Usage: app stats [-all] [filters]
+func runStats(cfg config.Config, args []string) error {
+
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	all := fs.Bool("all", false, "recompute the statistics of all the songs, not only the missing ones")
+	filters := songFilterFlags(fs)
+	fs.Parse(args)
+
+	db, err := openDB(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	service, _ := newService(cfg, db, newRemoteRepo(cfg.RemoteAPI))
+
+	n, err := service.ComputeSongStats(ctx, filters(), *all)
+
+	slog.Info("stats done", "all", *all, "analyzed", n)
+
+	return err
+}
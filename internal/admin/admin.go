@@ -0,0 +1,180 @@
+// Package admin is the handler of the admin server: metrics, pprof, the config dump and
+// reload, the build info and the runtime log level. It's not to be exposed publicly: the
+// requests are allowed from a unix socket, the loopback or with the admin token.
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"net/netip"
+	"runtime/debug"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"effective-mobile-go/internal/config"
+	"effective-mobile-go/internal/lib"
+	"effective-mobile-go/internal/logger"
+)
+
+// Runtime is the config in effect which can be reloaded.
+type Runtime interface {
+	Config() config.Config
+	Reload() ([]config.Change, error)
+}
+
+// New returns the admin handler. The config is dumped redacted, the level is the level
+// of the logger which can be changed at runtime.
+func New(rt Runtime, level *slog.LevelVar) http.Handler {
+
+	mux := http.NewServeMux()
+
+	mux.Handle("GET /metrics", promhttp.Handler())
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	mux.HandleFunc("GET /config", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, r, http.StatusOK, rt.Config().Redacted())
+	})
+
+	mux.HandleFunc("POST /reload", func(w http.ResponseWriter, r *http.Request) {
+
+		changes, err := rt.Reload()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		writeJSON(w, r, http.StatusOK, reload{Changes: changes})
+	})
+
+	mux.HandleFunc("GET /buildinfo", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, r, http.StatusOK, ReadBuildInfo())
+	})
+
+	mux.HandleFunc("GET /loglevel", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, r, http.StatusOK, logLevel{level.Level().String()})
+	})
+
+	mux.HandleFunc("PUT /loglevel", func(w http.ResponseWriter, r *http.Request) {
+
+		var req logLevel
+		var v slog.Level
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "body must be {\"level\": \"debug|info|warn|error\"}", http.StatusBadRequest)
+			return
+		}
+
+		if err := v.UnmarshalText(lib.StringAsBytes(req.Level)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		old := level.Level()
+		level.Set(v)
+		logger.GetLoggerFromContextOrDefault(r.Context()).Warn("log level changed", "from", old, "to", v)
+
+		writeJSON(w, r, http.StatusOK, logLevel{v.String()})
+	})
+
+	return mux
+}
+
+type reload struct {
+	Changes []config.Change `json:"changes"`
+}
+
+type logLevel struct {
+	Level string `json:"level"`
+}
+
+// BuildInfo describes the binary.
+type BuildInfo struct {
+	GoVersion string `json:"goVersion"`
+	Path      string `json:"path"`
+	Version   string `json:"version"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+func ReadBuildInfo() BuildInfo {
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return BuildInfo{Version: "unknown"}
+	}
+
+	info := BuildInfo{
+		GoVersion: bi.GoVersion,
+		Path:      bi.Main.Path,
+		Version:   bi.Main.Version,
+	}
+
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.time":
+			info.Time = s.Value
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+
+	return info
+}
+
+// Restrict allows the requests which come through a unix socket (the access is
+// controlled by the file permissions) or from the loopback. The others must have the
+// token as "Authorization: Bearer <token>"; if the token is empty, they are forbidden.
+func Restrict(h http.Handler, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if isLocal(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token != "" && ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		logger.GetLoggerFromContextOrDefault(r.Context()).Warn("admin access denied", "fromAddr", r.RemoteAddr, "path", r.URL.Path)
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	})
+}
+
+func isLocal(r *http.Request) bool {
+
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok && addr.Network() == "unix" {
+		return true
+	}
+
+	ap, err := netip.ParseAddrPort(r.RemoteAddr)
+	return err == nil && ap.Addr().IsLoopback()
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(v); err != nil {
+		logger.GetLoggerFromContextOrDefault(r.Context()).Error("can't write response", "error", err)
+	}
+}
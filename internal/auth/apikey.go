@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// apiKeyPrefix marks API keys, so that they can be told from JWT in the Authorization header.
+const apiKeyPrefix = "sk_"
+
+// GenerateAPIKey returns a new random key, its displayed prefix and its hash to store.
+func GenerateAPIKey() (key, prefix string, hash []byte) {
+
+	var b [32]byte
+	rand.Read(b[:]) // never returns an error
+
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b[:])
+
+	return key, key[:len(apiKeyPrefix)+8], HashAPIKey(key)
+}
+
+// HashAPIKey returns the hash the key is stored by. The keys are random 256 bits,
+// so plain SHA-256 is enough (unlike passwords they can't be brute-forced).
+func HashAPIKey(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+func isAPIKey(s string) bool {
+	return strings.HasPrefix(s, apiKeyPrefix)
+}
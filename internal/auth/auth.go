@@ -0,0 +1,160 @@
+// Package auth authenticates API callers by API keys and JWT bearer tokens.
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"effective-mobile-go/internal/config"
+	"effective-mobile-go/internal/model"
+)
+
+// ErrNoCredentials is returned if the request has no credentials at all.
+var ErrNoCredentials = errors.New("no credentials")
+
+type KeyStore interface {
+	GetAPIKeyByHash(_ context.Context, hash []byte) (model.APIKey, error)
+}
+
+type Authenticator struct {
+	keys      KeyStore
+	jwt       *KeySet // nil if JWT is not configured
+	issuer    string
+	audience  string
+	bootstrap []byte // hash of the bootstrap admin key
+	now       func() time.Time
+}
+
+func New(keys KeyStore, cfg config.Auth) (*Authenticator, error) {
+
+	if cfg.AnonymousRole != "" && !model.IsValidRole(cfg.AnonymousRole) {
+		return nil, fmt.Errorf("unknown anonymous role %q", cfg.AnonymousRole)
+	}
+
+	a := &Authenticator{
+		keys:     keys,
+		issuer:   cfg.JWTIssuer,
+		audience: cfg.JWTAudience,
+		now:      time.Now,
+	}
+
+	if cfg.JWKSFile != "" {
+		ks, err := LoadKeySet(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.jwt = ks
+	}
+
+	if cfg.BootstrapKey != "" {
+		a.bootstrap = HashAPIKey(cfg.BootstrapKey)
+	}
+
+	return a, nil
+}
+
+// Authenticate identifies the caller by the X-API-Key header or by the Authorization
+// bearer token (API key or JWT). Returns ErrNoCredentials if there are none, and
+// ErrUnauthorized if they are invalid.
+func (a *Authenticator) Authenticate(r *http.Request) (model.Principal, error) {
+	var zero model.Principal
+
+	cred := r.Header.Get("X-API-Key")
+
+	if cred == "" {
+		if s := r.Header.Get("Authorization"); s != "" {
+			scheme, token, _ := strings.Cut(s, " ")
+			if !strings.EqualFold(scheme, "Bearer") || token == "" {
+				return zero, fmt.Errorf("%w: bad authorization scheme", model.ErrUnauthorized)
+			}
+			cred = strings.TrimSpace(token)
+		}
+	}
+
+	if cred == "" {
+		return zero, ErrNoCredentials
+	}
+
+	if isAPIKey(cred) || r.Header.Get("X-API-Key") != "" {
+		return a.authenticateAPIKey(r.Context(), cred)
+	}
+
+	return a.authenticateJWT(cred)
+}
+
+func (a *Authenticator) authenticateAPIKey(ctx context.Context, key string) (model.Principal, error) {
+	var zero model.Principal
+
+	hash := HashAPIKey(key)
+
+	if a.bootstrap != nil && subtle.ConstantTimeCompare(hash, a.bootstrap) == 1 {
+		return model.Principal{
+			Method:  model.AuthAPIKey,
+			Subject: "bootstrap",
+			Name:    "bootstrap",
+			Roles:   []string{model.RoleAdmin},
+		}, nil
+	}
+
+	apiKey, err := a.keys.GetAPIKeyByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return zero, fmt.Errorf("%w: unknown or revoked api key", model.ErrUnauthorized)
+		}
+		return zero, err
+	}
+
+	return model.Principal{
+		Method:  model.AuthAPIKey,
+		Subject: strconv.FormatUint(apiKey.ID, 10),
+		Name:    apiKey.Name,
+		Roles:   apiKey.Roles,
+	}, nil
+}
+
+func (a *Authenticator) authenticateJWT(token string) (model.Principal, error) {
+	var zero model.Principal
+
+	if a.jwt == nil {
+		return zero, fmt.Errorf("%w: jwt is not configured", model.ErrUnauthorized)
+	}
+
+	claims, err := a.jwt.Verify(token, a.issuer, a.audience, a.now())
+	if err != nil {
+		return zero, fmt.Errorf("%w: %w", model.ErrUnauthorized, err)
+	}
+
+	if claims.Subject == "" {
+		return zero, fmt.Errorf("%w: no subject", model.ErrUnauthorized)
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.Subject
+	}
+
+	return model.Principal{
+		Method:  model.AuthJWT,
+		Subject: claims.Subject,
+		Name:    name,
+		Roles:   claims.Roles,
+	}, nil
+}
+
+type contextKey struct{}
+
+func ContextWithPrincipal(ctx context.Context, p model.Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// PrincipalFromContext returns the authenticated caller, ok is false for anonymous one.
+func PrincipalFromContext(ctx context.Context) (model.Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(model.Principal)
+	return p, ok
+}
@@ -0,0 +1,261 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"effective-mobile-go/internal/model"
+)
+
+var (
+	hsSecret  = []byte("0123456789abcdef0123456789abcdef")
+	rsaKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+	testNow   = time.Date(2025, 1, 27, 12, 0, 0, 0, time.UTC)
+)
+
+func testKeySet(t *testing.T) *KeySet {
+	t.Helper()
+
+	jwks := fmt.Sprintf(`{"keys":[
+		{"kty":"oct","kid":"hs","k":%q},
+		{"kty":"RSA","kid":"rs","alg":"RS256","n":%q,"e":%q}
+	]}`,
+		base64.RawURLEncoding.EncodeToString(hsSecret),
+		base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+	)
+
+	ks, err := ParseKeySet([]byte(jwks))
+	if err != nil {
+		t.Fatalf("ParseKeySet() error = %v", err)
+	}
+	return ks
+}
+
+func makeToken(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+
+	enc := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	signed := enc(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"}) + "." + enc(claims)
+
+	var sig []byte
+	switch alg {
+	case "HS256":
+		mac := hmac.New(sha256.New, hsSecret)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case "RS256":
+		digest := sha256.Sum256([]byte(signed))
+		var err error
+		sig, err = rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestKeySet_Verify(t *testing.T) {
+
+	ks := testKeySet(t)
+	exp := testNow.Add(time.Hour).Unix()
+
+	// RS256 header with the token signed by HMAC using the RSA public key as secret
+	confused := func() string {
+		enc := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+		signed := enc(`{"alg":"HS256","kid":"rs"}`) + "." + enc(fmt.Sprintf(`{"sub":"x","exp":%d}`, exp))
+		mac := hmac.New(sha256.New, rsaKey.N.Bytes())
+		mac.Write([]byte(signed))
+		return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}()
+
+	tests := []struct {
+		name     string
+		token    string
+		audience string
+		wantSub  string
+		wantErr  error
+	}{
+		{
+			"HS256",
+			makeToken(t, "HS256", "hs", map[string]any{"sub": "u1", "exp": exp}),
+			"",
+			"u1",
+			nil,
+		},
+		{
+			"RS256",
+			makeToken(t, "RS256", "rs", map[string]any{"sub": "u2", "exp": exp, "roles": []string{"admin"}}),
+			"",
+			"u2",
+			nil,
+		},
+		{
+			"RS256 without kid",
+			makeToken(t, "RS256", "", map[string]any{"sub": "u3", "exp": exp}),
+			"",
+			"u3",
+			nil,
+		},
+		{
+			"audience array",
+			makeToken(t, "HS256", "hs", map[string]any{"sub": "u4", "exp": exp, "aud": []string{"a", "songs"}}),
+			"songs",
+			"u4",
+			nil,
+		},
+		{
+			"wrong audience",
+			makeToken(t, "HS256", "hs", map[string]any{"sub": "u5", "exp": exp, "aud": "other"}),
+			"songs",
+			"",
+			ErrInvalidToken,
+		},
+		{
+			"expired",
+			makeToken(t, "HS256", "hs", map[string]any{"sub": "u6", "exp": testNow.Add(-time.Hour).Unix()}),
+			"",
+			"",
+			ErrTokenExpired,
+		},
+		{
+			"expired within leeway",
+			makeToken(t, "HS256", "hs", map[string]any{"sub": "u7", "exp": testNow.Add(-time.Second).Unix()}),
+			"",
+			"u7",
+			nil,
+		},
+		{
+			"not yet valid",
+			makeToken(t, "HS256", "hs", map[string]any{"sub": "u8", "exp": exp, "nbf": testNow.Add(time.Hour).Unix()}),
+			"",
+			"",
+			ErrInvalidToken,
+		},
+		{
+			"no exp",
+			makeToken(t, "HS256", "hs", map[string]any{"sub": "u9"}),
+			"",
+			"",
+			ErrInvalidToken,
+		},
+		{
+			"alg none",
+			makeToken(t, "none", "", map[string]any{"sub": "u10", "exp": exp}),
+			"",
+			"",
+			ErrInvalidToken,
+		},
+		{
+			"alg confusion",
+			confused,
+			"",
+			"",
+			ErrInvalidToken,
+		},
+		{
+			"tampered",
+			makeToken(t, "HS256", "hs", map[string]any{"sub": "u11", "exp": exp}) + "x",
+			"",
+			"",
+			ErrInvalidToken,
+		},
+		{
+			"garbage",
+			"not.a.token",
+			"",
+			"",
+			ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ks.Verify(tt.token, "", tt.audience, testNow)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if claims.Subject != tt.wantSub {
+				t.Errorf("Verify() sub = %q, want %q", claims.Subject, tt.wantSub)
+			}
+		})
+	}
+}
+
+type fakeKeyStore map[string]model.APIKey
+
+func (s fakeKeyStore) GetAPIKeyByHash(_ context.Context, hash []byte) (model.APIKey, error) {
+	if key, ok := s[string(hash)]; ok {
+		return key, nil
+	}
+	return model.APIKey{}, model.ErrNotFound
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+
+	key, _, hash := GenerateAPIKey()
+
+	a := &Authenticator{
+		keys:      fakeKeyStore{string(hash): {ID: 7, Name: "importer", Roles: []string{"editor"}}},
+		jwt:       testKeySet(t),
+		bootstrap: HashAPIKey("sk_bootstrap"),
+		now:       func() time.Time { return testNow },
+	}
+
+	token := makeToken(t, "HS256", "hs", map[string]any{"sub": "u1", "name": "Alice", "exp": testNow.Add(time.Hour).Unix()})
+
+	tests := []struct {
+		name     string
+		header   string
+		value    string
+		wantName string
+		wantErr  error
+	}{
+		{"no credentials", "", "", "", ErrNoCredentials},
+		{"X-API-Key", "X-API-Key", key, "importer", nil},
+		{"bearer api key", "Authorization", "Bearer " + key, "importer", nil},
+		{"bootstrap key", "X-API-Key", "sk_bootstrap", "bootstrap", nil},
+		{"unknown api key", "X-API-Key", "sk_unknown", "", model.ErrUnauthorized},
+		{"bearer jwt", "Authorization", "Bearer " + token, "Alice", nil},
+		{"bad jwt", "Authorization", "Bearer abc.def.ghi", "", model.ErrUnauthorized},
+		{"basic scheme", "Authorization", "Basic dXNlcjpwYXNz", "", model.ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := httptest.NewRequest("GET", "/songs", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+
+			p, err := a.Authenticate(r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if p.Name != tt.wantName {
+				t.Errorf("Authenticate() name = %q, want %q", p.Name, tt.wantName)
+			}
+		})
+	}
+}
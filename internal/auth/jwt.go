@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// leeway is the allowed clock skew for exp and nbf.
+const leeway = time.Minute
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// KeySet is the set of keys the JWT tokens are verified against. The HMAC keys verify
+// HS256, the RSA keys verify RS256. The algorithm is bound to the key type, so a token
+// can't make us check the RSA signature as HMAC with the public key.
+type KeySet struct {
+	keys []jwk
+}
+
+type jwk struct {
+	kid  string
+	alg  string
+	hmac []byte
+	rsa  *rsa.PublicKey
+}
+
+// LoadKeySet reads the key set from the JWKS (RFC 7517) file. Only "oct" and "RSA" keys
+// are supported.
+func LoadKeySet(fileName string) (*KeySet, error) {
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	ks, err := ParseKeySet(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+
+	return ks, nil
+}
+
+func ParseKeySet(data []byte) (*KeySet, error) {
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Alg string `json:"alg"`
+			Use string `json:"use"`
+			K   string `json:"k"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+
+	ks := &KeySet{}
+
+	for i, k := range jwks.Keys {
+
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key := jwk{kid: k.Kid}
+
+		switch k.Kty {
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(k.K)
+			if err != nil || len(secret) == 0 {
+				return nil, fmt.Errorf("jwks: key %d: bad k", i)
+			}
+			key.alg, key.hmac = "HS256", secret
+
+		case "RSA":
+			n, err1 := base64.RawURLEncoding.DecodeString(k.N)
+			e, err2 := base64.RawURLEncoding.DecodeString(k.E)
+			if err1 != nil || err2 != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+				return nil, fmt.Errorf("jwks: key %d: bad n or e", i)
+			}
+			key.alg = "RS256"
+			key.rsa = &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+
+		default:
+			return nil, fmt.Errorf("jwks: key %d: unsupported kty %q", i, k.Kty)
+		}
+
+		if k.Alg != "" && k.Alg != key.alg {
+			return nil, fmt.Errorf("jwks: key %d: unsupported alg %q", i, k.Alg)
+		}
+
+		ks.keys = append(ks.keys, key)
+	}
+
+	if len(ks.keys) == 0 {
+		return nil, errors.New("jwks: no keys")
+	}
+
+	return ks, nil
+}
+
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Name      string   `json:"name"`
+	Roles     []string `json:"roles"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf"`
+}
+
+// audience is either a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(a))
+}
+
+// Verify checks the signature and the time limits of the token and returns its claims.
+// The token must have exp. Empty issuer or audience are not checked.
+func (ks *KeySet) Verify(token, issuer, audience string, now time.Time) (Claims, error) {
+	var zero Claims
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return zero, ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return zero, ErrInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return zero, ErrInvalidToken
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+
+	if !ks.verifySignature(header.Alg, header.Kid, signed, sig) {
+		return zero, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return zero, ErrInvalidToken
+	}
+
+	switch {
+	case claims.ExpiresAt == 0:
+		return zero, ErrInvalidToken
+	case now.After(time.Unix(claims.ExpiresAt, 0).Add(leeway)):
+		return zero, ErrTokenExpired
+	case claims.NotBefore != 0 && now.Add(leeway).Before(time.Unix(claims.NotBefore, 0)):
+		return zero, ErrInvalidToken
+	case issuer != "" && claims.Issuer != issuer:
+		return zero, ErrInvalidToken
+	case audience != "" && !slices.Contains(claims.Audience, audience):
+		return zero, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+func (ks *KeySet) verifySignature(alg, kid string, signed, sig []byte) bool {
+
+	digest := sha256.Sum256(signed)
+
+	for _, key := range ks.keys {
+
+		if key.alg != alg || kid != "" && key.kid != "" && key.kid != kid {
+			continue
+		}
+
+		switch alg {
+		case "HS256":
+			mac := hmac.New(sha256.New, key.hmac)
+			mac.Write(signed)
+			if hmac.Equal(mac.Sum(nil), sig) {
+				return true
+			}
+		case "RS256":
+			if rsa.VerifyPKCS1v15(key.rsa, crypto.SHA256, digest[:], sig) == nil {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func decodeSegment(s string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
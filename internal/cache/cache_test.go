@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+
+	now := time.Now()
+	c := New[string, int](2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	get := func(key string, want int, wantOK bool) {
+		t.Helper()
+		got, ok := c.Get(key)
+		if got != want || ok != wantOK {
+			t.Fatalf("Get(%q) = %d, %v, want %d, %v", key, got, ok, want, wantOK)
+		}
+	}
+
+	get("a", 0, false)
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	get("a", 1, true)
+
+	c.Set("c", 3) // evicts b (least recently used)
+	get("b", 0, false)
+	get("a", 1, true)
+	get("c", 3, true)
+
+	c.SetWithTTL("a", 10, time.Second)
+	now = now.Add(2 * time.Second)
+	get("a", 0, false) // expired
+	get("c", 3, true)
+
+	c.Delete("c")
+	get("c", 0, false)
+
+	c.Set("d", 4)
+	c.SetWithTTL("d", 5, 0) // deletes
+	get("d", 0, false)
+
+	want := Stats{Size: 2, Len: 0, Hits: 4, Misses: 5, Evictions: 1}
+	if got := c.Stats(); got != want {
+		t.Fatalf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCache_zeroSize(t *testing.T) {
+	c := New[int, int](0, time.Minute)
+	c.Set(1, 1)
+	if _, ok := c.Get(1); ok {
+		t.Fatal("zero size cache must cache nothing")
+	}
+}
@@ -1,23 +1,116 @@
 package config
 
 import (
+	"errors"
 	"log/slog"
+	"time"
 )
 
+// DB is the Postgres connection, see ConnString. The DSN replaces the connection fields,
+// the pool settings apply anyway. Zero pool values are the database/sql defaults.
 type DB struct {
-	Host     string
-	Port     int
-	Name     string
-	User     string
-	Password string
+	DSN              string // postgres:// URL or key=value DSN
+	Host             string // or the unix socket directory
+	Port             int
+	Name             string
+	User             string
+	Password         string // or read from PasswordFile (Docker secrets)
+	PasswordFile     string
+	SSLMode          string
+	SSLRootCert      string
+	SSLCert          string
+	SSLKey           string
+	AppName          string
+	StatementTimeout time.Duration // zero is no timeout
+	SearchPath       string
+	ConnectTimeout   time.Duration
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
 }
 
+// Server timeouts are of the API server, the admin one has no write timeout. The
+// ShutdownTimeout is given to the running requests to finish.
 type Server struct {
-	Port int
+	Port            int
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+}
+
+// Admin is the server of metrics, pprof and runtime controls, not to be exposed publicly.
+// It listens on the unix socket if Socket is set, otherwise on the port. The requests
+// not from the socket or the loopback must have the Token.
+type Admin struct {
+	Port   int
+	Socket string
+	Token  string
 }
 
 type RemoteAPI struct {
-	URL string
+	URL     string
+	Timeout time.Duration
+}
+
+type Service struct {
+	// AdvisoryLock serializes the check and the insert of the same song with Postgres
+	// advisory lock instead of retrying the serializable transaction on conflicts.
+	AdvisoryLock bool
+}
+
+// Cache sizes are in entries, zero size disables the cache.
+type Cache struct {
+	RemoteSize        int
+	RemoteTTL         time.Duration
+	RemoteNegativeTTL time.Duration // for songs not found remotely
+	SongSize          int
+	SongTTL           time.Duration
+}
+
+type Auth struct {
+	// Required rejects anonymous requests. If false, the credentials are still checked
+	// if present, which allows to create the first API keys with BootstrapKey.
+	Required      bool
+	AnonymousRole string // the role of anonymous callers if not Required, empty is none
+	BootstrapKey  string // static admin API key
+	JWKSFile      string // JWT is not accepted if empty
+	JWTIssuer     string
+	JWTAudience   string
+}
+
+// RateLimit limits requests per client (API key or IP). The remote limit is for the
+// routes that call the remote API, the write one is for the other modifying routes.
+type RateLimit struct {
+	Enabled  bool
+	Postgres bool // share the counters between instances through the DB
+	Read     Rate
+	Write    Rate
+	Remote   Rate
+}
+
+type Tracing struct {
+	Exporter    string // none, stdout or otlp
+	Endpoint    string // OTLP/HTTP endpoint URL, the exporter's default if empty
+	ServiceName string
+}
+
+// Health configures the readiness probe. The remote API is checked only if RemoteTTL is
+// not zero, the result is reused for that time. ShutdownDelay is the time between the
+// readiness failing on shutdown and the server stopping to accept requests.
+type Health struct {
+	Timeout       time.Duration
+	RemoteTTL     time.Duration
+	ShutdownDelay time.Duration
+}
+
+// OpenAPI validates the API requests against the generated spec. The responses are
+// buffered for the validation, so it's for dev and test only.
+type OpenAPI struct {
+	Validate          bool
+	ValidateResponses bool
 }
 
 type Logger struct {
@@ -27,35 +120,127 @@ type Logger struct {
 
 type Config struct {
 	Server    Server
+	Admin     Admin
 	DB        DB
 	RemoteAPI RemoteAPI
+	Service   Service
+	Cache     Cache
+	Auth      Auth
+	RateLimit RateLimit
+	Tracing   Tracing
+	Health    Health
+	OpenAPI   OpenAPI
 	Logger    Logger
 }
 
-func Load() (Config, error) {
+// Load reads the config from the defaults, the file, the env and the overrides, each
+// layer overriding the previous one. The keys are the env names, see readFile for the
+// file keys. The config is validated, all errors are reported at once.
+func Load(opts Options) (Config, error) {
+	cfg, _, err := LoadSettings(opts)
+	return cfg, err
+}
+
+// LoadSettings is Load which also returns the effective settings in order of definition.
+func LoadSettings(opts Options) (Config, []Setting, error) {
 
-	var ge getenv
+	l := newLoader(opts)
 	const required = true
 
 	cfg := Config{
 		Server: Server{
-			Port: ge.Int("SERVER_PORT", required, 8080),
+			Port:            l.Int("SERVER_PORT", required, 8080),
+			ReadTimeout:     l.Duration("SERVER_READ_TIMEOUT", !required, 10*time.Second),
+			WriteTimeout:    l.Duration("SERVER_WRITE_TIMEOUT", !required, 30*time.Second),
+			IdleTimeout:     l.Duration("SERVER_IDLE_TIMEOUT", !required, time.Minute),
+			ShutdownTimeout: l.Duration("SERVER_SHUTDOWN_TIMEOUT", !required, 10*time.Second),
+		},
+		Admin: Admin{
+			Port:   l.Int("ADMIN_PORT", !required, 9090),
+			Socket: l.String("ADMIN_SOCKET", !required, ""),
+			Token:  l.String("ADMIN_TOKEN", !required, ""),
 		},
 		DB: DB{
-			Host:     ge.String("DB_HOST", !required, "localhost"),
-			Port:     ge.Int("DB_PORT", !required, 5432),
-			Name:     ge.String("DB_NAME", required, "postgres"),
-			User:     ge.String("DB_USER", required, "postgres"),
-			Password: ge.String("DB_PASS", required, "postgres"),
+			DSN:              l.String("DB_DSN", !required, ""),
+			Host:             l.String("DB_HOST", !required, "localhost"),
+			Port:             l.Int("DB_PORT", !required, 5432),
+			Name:             l.String("DB_NAME", !required, ""),
+			User:             l.String("DB_USER", !required, ""),
+			Password:         l.String("DB_PASS", !required, ""),
+			PasswordFile:     l.String("DB_PASS_FILE", !required, ""),
+			SSLMode:          l.String("DB_SSLMODE", !required, "prefer"),
+			SSLRootCert:      l.String("DB_SSLROOTCERT", !required, ""),
+			SSLCert:          l.String("DB_SSLCERT", !required, ""),
+			SSLKey:           l.String("DB_SSLKEY", !required, ""),
+			AppName:          l.String("DB_APP_NAME", !required, "song-library"),
+			StatementTimeout: l.Duration("DB_STATEMENT_TIMEOUT", !required, 0),
+			SearchPath:       l.String("DB_SEARCH_PATH", !required, ""),
+			ConnectTimeout:   l.Duration("DB_CONNECT_TIMEOUT", !required, 10*time.Second),
+			MaxOpenConns:     l.Int("DB_MAX_OPEN_CONNS", !required, 25),
+			MaxIdleConns:     l.Int("DB_MAX_IDLE_CONNS", !required, 5),
+			ConnMaxLifetime:  l.Duration("DB_CONN_MAX_LIFETIME", !required, 30*time.Minute),
+			ConnMaxIdleTime:  l.Duration("DB_CONN_MAX_IDLE_TIME", !required, 5*time.Minute),
 		},
 		RemoteAPI: RemoteAPI{
-			URL: ge.String("REMOTE_API_URL", required, "http://localhost:8081"),
+			URL:     l.String("REMOTE_API_URL", required, "http://localhost:8081"),
+			Timeout: l.Duration("REMOTE_API_TIMEOUT", !required, 10*time.Second),
+		},
+		Service: Service{
+			AdvisoryLock: l.Bool("SERVICE_ADVISORY_LOCK", !required, false),
+		},
+		Cache: Cache{
+			RemoteSize:        l.Int("CACHE_REMOTE_SIZE", !required, 10000),
+			RemoteTTL:         l.Duration("CACHE_REMOTE_TTL", !required, time.Hour),
+			RemoteNegativeTTL: l.Duration("CACHE_REMOTE_NEGATIVE_TTL", !required, 5*time.Minute),
+			SongSize:          l.Int("CACHE_SONG_SIZE", !required, 10000),
+			SongTTL:           l.Duration("CACHE_SONG_TTL", !required, time.Minute),
+		},
+		Auth: Auth{
+			Required:      l.Bool("AUTH_REQUIRED", !required, false),
+			AnonymousRole: l.String("AUTH_ANONYMOUS_ROLE", !required, "reader"),
+			BootstrapKey:  l.String("AUTH_BOOTSTRAP_KEY", !required, ""),
+			JWKSFile:      l.String("AUTH_JWKS_FILE", !required, ""),
+			JWTIssuer:     l.String("AUTH_JWT_ISSUER", !required, ""),
+			JWTAudience:   l.String("AUTH_JWT_AUDIENCE", !required, ""),
+		},
+		RateLimit: RateLimit{
+			Enabled:  l.Bool("RATELIMIT_ENABLED", !required, false),
+			Postgres: l.Bool("RATELIMIT_POSTGRES", !required, false),
+			Read:     l.Rate("RATELIMIT_READ", !required, Rate{600, time.Minute}),
+			Write:    l.Rate("RATELIMIT_WRITE", !required, Rate{60, time.Minute}),
+			Remote:   l.Rate("RATELIMIT_REMOTE", !required, Rate{10, time.Minute}),
+		},
+		Tracing: Tracing{
+			Exporter:    l.String("TRACING_EXPORTER", !required, "none"),
+			Endpoint:    l.String("TRACING_OTLP_ENDPOINT", !required, ""),
+			ServiceName: l.String("TRACING_SERVICE_NAME", !required, "song-library"),
+		},
+		Health: Health{
+			Timeout:       l.Duration("HEALTH_TIMEOUT", !required, 2*time.Second),
+			RemoteTTL:     l.Duration("HEALTH_REMOTE_TTL", !required, 0),
+			ShutdownDelay: l.Duration("HEALTH_SHUTDOWN_DELAY", !required, 5*time.Second),
+		},
+		OpenAPI: OpenAPI{
+			Validate:          l.Bool("OPENAPI_VALIDATE", !required, false),
+			ValidateResponses: l.Bool("OPENAPI_VALIDATE_RESPONSES", !required, false),
 		},
 		Logger: Logger{
-			Level:     ge.LogLevel("LOG_LEVEL", !required, slog.LevelInfo),
-			PlainText: ge.Bool("LOG_PLAINTEXT", !required, false),
+			Level:     l.LogLevel("LOG_LEVEL", !required, slog.LevelInfo),
+			PlainText: l.Bool("LOG_PLAINTEXT", !required, false),
 		},
 	}
 
-	return cfg, ge.err
+	if cfg.DB.PasswordFile != "" {
+		if cfg.DB.Password != "" {
+			l.errs = append(l.errs, errors.New("DB_PASS and DB_PASS_FILE are mutually exclusive"))
+		}
+		cfg.DB.Password = l.secretFile("DB_PASS_FILE", cfg.DB.PasswordFile)
+	}
+
+	errs := append(l.errs, l.unused()...)
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return cfg, l.settings, errors.Join(errs...)
 }
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ConnString returns the DSN if set (as is, the other fields are ignored, see Validate),
+// otherwise the postgres:// URL of the connection fields. The parts are escaped, so the
+// password may have any characters. If the host is a directory, it's the unix socket one.
+// The statement timeout and the search path are sent as the run-time parameters of the
+// session.
+func (db DB) ConnString() string {
+
+	if db.DSN != "" {
+		return db.DSN
+	}
+
+	u := url.URL{Scheme: "postgres", Path: "/" + db.Name}
+	q := url.Values{}
+
+	switch {
+	case db.User == "":
+	case db.Password == "":
+		u.User = url.User(db.User)
+	default:
+		u.User = url.UserPassword(db.User, db.Password)
+	}
+
+	if strings.HasPrefix(db.Host, "/") {
+		q.Set("host", db.Host)
+		q.Set("port", strconv.Itoa(db.Port))
+	} else {
+		u.Host = net.JoinHostPort(db.Host, strconv.Itoa(db.Port))
+	}
+
+	set := func(key, value string) {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+
+	set("sslmode", db.SSLMode)
+	set("sslrootcert", db.SSLRootCert)
+	set("sslcert", db.SSLCert)
+	set("sslkey", db.SSLKey)
+	set("application_name", db.AppName)
+	set("search_path", db.SearchPath)
+
+	if db.StatementTimeout > 0 {
+		q.Set("statement_timeout", strconv.FormatInt(db.StatementTimeout.Milliseconds(), 10))
+	}
+
+	if db.ConnectTimeout > 0 {
+		q.Set("connect_timeout", strconv.Itoa(int(math.Ceil(db.ConnectTimeout.Seconds()))))
+	}
+
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestDB_ConnString(t *testing.T) {
+
+	db := DB{
+		Host:             "db.local",
+		Port:             5433,
+		Name:             "songs",
+		User:             "app",
+		Password:         "p@ss:w/rd?#%&=",
+		SSLMode:          "disable",
+		AppName:          "song-library",
+		StatementTimeout: 1500 * time.Millisecond,
+		SearchPath:       "songs,public",
+		ConnectTimeout:   2500 * time.Millisecond,
+	}
+
+	cfg, err := pgconn.ParseConfig(db.ConnString())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Host != db.Host || cfg.Port != 5433 || cfg.Database != db.Name || cfg.User != db.User {
+		t.Errorf("connection = %s:%d/%s as %s", cfg.Host, cfg.Port, cfg.Database, cfg.User)
+	}
+
+	if cfg.Password != db.Password {
+		t.Errorf("password = %q, want %q", cfg.Password, db.Password)
+	}
+
+	if cfg.TLSConfig != nil {
+		t.Error("TLS is on, want sslmode=disable")
+	}
+
+	if cfg.ConnectTimeout != 3*time.Second {
+		t.Errorf("connect timeout = %s, want 3s", cfg.ConnectTimeout)
+	}
+
+	for key, want := range map[string]string{
+		"application_name":  "song-library",
+		"statement_timeout": "1500",
+		"search_path":       "songs,public",
+	} {
+		if got := cfg.RuntimeParams[key]; got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestDB_ConnString_socket(t *testing.T) {
+
+	db := DB{Host: "/var/run/postgresql", Port: 5432, Name: "songs", User: "app"}
+
+	cfg, err := pgconn.ParseConfig(db.ConnString())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Host != db.Host || cfg.Password != "" {
+		t.Errorf("host = %q, password = %q", cfg.Host, cfg.Password)
+	}
+}
+
+func TestDB_ConnString_dsn(t *testing.T) {
+
+	db := DB{DSN: "host=db user=app", Host: "localhost", Name: "songs"}
+
+	if got := db.ConnString(); got != db.DSN {
+		t.Errorf("ConnString() = %q, want DSN", got)
+	}
+}
+
+func Test_redactDSN(t *testing.T) {
+
+	tests := []struct {
+		dsn, want string
+	}{
+		{"postgres://app:secret@db/songs?sslmode=require", "postgres://app:REDACTED@db/songs?sslmode=require"},
+		{"postgres://app@db/songs?password=secret&sslmode=require", "postgres://app@db/songs?password=REDACTED&sslmode=require"},
+		{"host=db password=secret user=app", "host=db password=REDACTED user=app"},
+		{"host=db password='se cret' user=app", "host=db password=REDACTED user=app"},
+		{"postgres://app:secret@db:port/songs", "REDACTED"},
+	}
+
+	for _, tt := range tests {
+		if got := redactDSN(tt.dsn); got != tt.want {
+			t.Errorf("redactDSN(%q) = %q, want %q", tt.dsn, got, tt.want)
+		}
+	}
+}
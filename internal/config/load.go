@@ -0,0 +1,263 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"effective-mobile-go/internal/lib"
+)
+
+// The sources of the settings, the later ones override the earlier.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+var ErrRequired = errors.New("is required")
+
+// Options are the sources of the config besides the defaults and the env.
+type Options struct {
+	File      string    // YAML file, not read if empty
+	Overrides Overrides // from the command line
+}
+
+// Overrides are the settings by key, it's flag.Value of KEY=value.
+type Overrides map[string]string
+
+func (o *Overrides) Set(s string) error {
+
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("bad override %q: want KEY=value", s)
+	}
+
+	if *o == nil {
+		*o = Overrides{}
+	}
+	(*o)[normalizeKey(key)] = value
+
+	return nil
+}
+
+func (o *Overrides) String() string {
+	return fmt.Sprint(map[string]string(*o))
+}
+
+// Setting is the effective value of the key and where it comes from.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// loader reads the settings from the layers. The errors are collected, so all of them
+// are reported at once.
+type loader struct {
+	file      map[string]string
+	overrides Overrides
+	used      map[string]bool
+	settings  []Setting
+	errs      []error
+}
+
+func newLoader(opts Options) *loader {
+
+	l := &loader{overrides: opts.Overrides, used: map[string]bool{}}
+
+	if opts.File != "" {
+		file, err := readFile(opts.File)
+		if err != nil {
+			l.errs = append(l.errs, err)
+		}
+		l.file = file
+	}
+
+	return l
+}
+
+func (l *loader) lookup(key string) (value, source string, ok bool) {
+
+	l.used[key] = true
+
+	if s, ok := l.overrides[key]; ok {
+		return s, SourceFlag, true
+	}
+	if s, ok := os.LookupEnv(key); ok {
+		return s, SourceEnv, true
+	}
+	if s, ok := l.file[key]; ok {
+		return s, SourceFile, true
+	}
+
+	return "", "", false
+}
+
+// get returns the value of the key parsed from the first layer which has it, or the
+// default value.
+func get[T any](l *loader, key string, required bool, defaultValue T, parse func(string) (T, error)) T {
+
+	s, source, ok := l.lookup(key)
+	if !ok {
+
+		if required {
+			l.errs = append(l.errs, fmt.Errorf("%s %w", key, ErrRequired))
+		}
+
+		l.settings = append(l.settings, Setting{Key: key, Value: fmt.Sprint(defaultValue), Source: SourceDefault})
+		return defaultValue
+	}
+
+	l.settings = append(l.settings, Setting{Key: key, Value: s, Source: source})
+
+	v, err := parse(s)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s (%s): %w", key, source, err))
+		return defaultValue
+	}
+
+	return v
+}
+
+// unused returns the errors of the file and override keys which are not settings,
+// most likely typos.
+func (l *loader) unused() []error {
+
+	var errs []error
+
+	for _, layer := range []struct {
+		source string
+		keys   map[string]string
+	}{{SourceFile, l.file}, {SourceFlag, l.overrides}} {
+
+		var keys []string
+		for key := range layer.keys {
+			if !l.used[key] {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			errs = append(errs, fmt.Errorf("%s (%s): unknown key", key, layer.source))
+		}
+	}
+
+	return errs
+}
+
+func (l *loader) String(key string, required bool, defaultValue string) string {
+	return get(l, key, required, defaultValue, func(s string) (string, error) {
+		return s, nil
+	})
+}
+
+func (l *loader) Int(key string, required bool, defaultValue int) int {
+	return get(l, key, required, defaultValue, strconv.Atoi)
+}
+
+func (l *loader) LogLevel(key string, required bool, defaultValue slog.Level) slog.Level {
+	return get(l, key, required, defaultValue, func(s string) (slog.Level, error) {
+		var v slog.Level
+		err := v.UnmarshalText(lib.StringAsBytes(s))
+		return v, err
+	})
+}
+
+func (l *loader) Bool(key string, required bool, defaultValue bool) bool {
+	return get(l, key, required, defaultValue, func(s string) (bool, error) {
+		switch strings.ToLower(s) {
+		case "true", "yes", "on", "1":
+			return true, nil
+		case "false", "no", "off", "0":
+			return false, nil
+		}
+		return false, fmt.Errorf("bad value %q: want true/false, yes/no, on/off or 1/0", s)
+	})
+}
+
+func (l *loader) Duration(key string, required bool, defaultValue time.Duration) time.Duration {
+	return get(l, key, required, defaultValue, time.ParseDuration)
+}
+
+func (l *loader) Rate(key string, required bool, defaultValue Rate) Rate {
+	return get(l, key, required, defaultValue, ParseRate)
+}
+
+// secretFile returns the content of the file of the secret without the trailing newline.
+func (l *loader) secretFile(key, name string) string {
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: %w", key, err))
+		return ""
+	}
+
+	return strings.TrimRight(string(b), "\r\n")
+}
+
+// readFile reads the YAML file of the settings. The nested keys are joined, so
+//
+//	server:
+//	  read_timeout: 5s
+//
+// is SERVER_READ_TIMEOUT, as is `server.read_timeout: 5s` or `SERVER_READ_TIMEOUT: 5s`.
+func readFile(name string) (map[string]string, error) {
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]any
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	settings := map[string]string{}
+	if err := flatten(settings, "", doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return settings, nil
+}
+
+func flatten(settings map[string]string, prefix string, m map[string]any) error {
+
+	for k, v := range m {
+
+		key := normalizeKey(k)
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		switch v := v.(type) {
+		case map[string]any:
+			if err := flatten(settings, key, v); err != nil {
+				return err
+			}
+		case []any:
+			return fmt.Errorf("%s: lists are not supported", key)
+		case nil:
+			settings[key] = ""
+		default:
+			settings[key] = fmt.Sprint(v)
+		}
+	}
+
+	return nil
+}
+
+// normalizeKey returns the env name of the key: "server.read-timeout" is SERVER_READ_TIMEOUT.
+func normalizeKey(key string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(strings.TrimSpace(key)))
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rate is the number of requests allowed per period, like "60/m". Zero Count is unlimited.
+type Rate struct {
+	Count  int
+	Period time.Duration
+}
+
+// ParseRate parses "N/s", "N/m", "N/h" or "N/<duration>" (e.g. "100/10s").
+func ParseRate(s string) (Rate, error) {
+
+	count, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("bad rate %q: want N/period", s)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || n < 0 {
+		return Rate{}, fmt.Errorf("bad rate %q: bad count", s)
+	}
+
+	var d time.Duration
+
+	switch period = strings.TrimSpace(period); period {
+	case "s":
+		d = time.Second
+	case "m":
+		d = time.Minute
+	case "h":
+		d = time.Hour
+	default:
+		d, err = time.ParseDuration(period)
+		if err != nil || d <= 0 {
+			return Rate{}, fmt.Errorf("bad rate %q: bad period", s)
+		}
+	}
+
+	return Rate{Count: n, Period: d}, nil
+}
+
+func (r Rate) String() string {
+	return fmt.Sprintf("%d/%s", r.Count, r.Period)
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const redacted = "REDACTED"
+
+// secretKeys are the keys of the settings which are secrets, see Config.Redacted.
+var secretKeys = []string{"DB_PASS", "AUTH_BOOTSTRAP_KEY", "ADMIN_TOKEN"}
+
+// Redacted returns the copy of the config with the secrets replaced, to be shown.
+func (cfg Config) Redacted() Config {
+
+	redact(&cfg.DB.Password)
+	redact(&cfg.Auth.BootstrapKey)
+	redact(&cfg.Admin.Token)
+
+	cfg.RemoteAPI.URL = redactURL(cfg.RemoteAPI.URL)
+	cfg.DB.DSN = redactDSN(cfg.DB.DSN)
+
+	return cfg
+}
+
+// Redacted returns the setting with the secret value replaced, to be shown.
+func (s Setting) Redacted() Setting {
+
+	switch {
+	case slices.Contains(secretKeys, s.Key):
+		redact(&s.Value)
+	case s.Key == "DB_DSN":
+		s.Value = redactDSN(s.Value)
+	default:
+		s.Value = redactURL(s.Value)
+	}
+
+	return s
+}
+
+func redact(s *string) {
+	if *s != "" {
+		*s = redacted
+	}
+}
+
+// redactURL replaces the password of the URL, if s is URL and has the password.
+func redactURL(s string) string {
+
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+
+	if _, ok := u.User.Password(); !ok {
+		return s
+	}
+
+	u.User = url.UserPassword(u.User.Username(), redacted)
+	return u.String()
+}
+
+var dsnPassword = regexp.MustCompile(`(?i)(\bpassword\s*=\s*)('(?:[^'\\]|\\.)*'|\S*)`)
+
+// redactDSN replaces the password of the URL (in the user info or the query) or of the
+// key=value DSN. The URL which can't be parsed is replaced as a whole.
+func redactDSN(s string) string {
+
+	if !strings.Contains(s, "://") {
+		return dsnPassword.ReplaceAllString(s, "${1}"+redacted)
+	}
+
+	u, err := url.Parse(redactURL(s))
+	if err != nil {
+		return redacted
+	}
+
+	if q := u.Query(); q.Has("password") {
+		q.Set("password", redacted)
+		u.RawQuery = q.Encode()
+	}
+
+	return u.String()
+}
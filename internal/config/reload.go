@@ -0,0 +1,74 @@
+package config
+
+import "slices"
+
+// reloadableKeys are the settings which can be changed without a restart, see Reloaded.
+var reloadableKeys = []string{
+	"LOG_LEVEL",
+	"LOG_PLAINTEXT",
+	"REMOTE_API_URL",
+	"REMOTE_API_TIMEOUT",
+	"RATELIMIT_READ",
+	"RATELIMIT_WRITE",
+	"RATELIMIT_REMOTE",
+	"CACHE_REMOTE_TTL",
+	"CACHE_REMOTE_NEGATIVE_TTL",
+	"CACHE_SONG_TTL",
+	"HEALTH_TIMEOUT",
+	"HEALTH_SHUTDOWN_DELAY",
+	"SERVER_SHUTDOWN_TIMEOUT",
+}
+
+// Change is the setting changed in the reloaded config, the values are redacted.
+type Change struct {
+	Key     string `json:"key"`
+	Old     string `json:"old"`
+	New     string `json:"new"`
+	Applied bool   `json:"applied"` // false if it needs a restart
+}
+
+// Reloaded returns the config in effect after the reload of cur: the reloadable settings
+// are taken from the loaded config, the others stay as they are (and are reported as not
+// applied on every reload until the restart).
+func Reloaded(cur Config, curSettings []Setting, loaded Config, loadedSettings []Setting) (Config, []Setting, []Change) {
+
+	next := cur
+
+	next.Logger = loaded.Logger
+	next.RemoteAPI = loaded.RemoteAPI
+	next.RateLimit.Read = loaded.RateLimit.Read
+	next.RateLimit.Write = loaded.RateLimit.Write
+	next.RateLimit.Remote = loaded.RateLimit.Remote
+	next.Cache.RemoteTTL = loaded.Cache.RemoteTTL
+	next.Cache.RemoteNegativeTTL = loaded.Cache.RemoteNegativeTTL
+	next.Cache.SongTTL = loaded.Cache.SongTTL
+	next.Health.Timeout = loaded.Health.Timeout
+	next.Health.ShutdownDelay = loaded.Health.ShutdownDelay
+	next.Server.ShutdownTimeout = loaded.Server.ShutdownTimeout
+
+	var changes []Change
+	settings := slices.Clone(curSettings)
+
+	for i, s := range settings {
+
+		j := slices.IndexFunc(loadedSettings, func(v Setting) bool { return v.Key == s.Key })
+		if j < 0 || loadedSettings[j].Value == s.Value {
+			continue
+		}
+
+		change := Change{
+			Key:     s.Key,
+			Old:     s.Redacted().Value,
+			New:     loadedSettings[j].Redacted().Value,
+			Applied: slices.Contains(reloadableKeys, s.Key),
+		}
+
+		if change.Applied {
+			settings[i] = loadedSettings[j]
+		}
+
+		changes = append(changes, change)
+	}
+
+	return next, settings, changes
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestReloaded(t *testing.T) {
+
+	setRequiredEnv(t)
+
+	cur, curSettings, err := LoadSettings(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("CACHE_SONG_TTL", "7m")
+	t.Setenv("SERVER_PORT", "8088")
+	t.Setenv("DB_PASS", "new-secret")
+
+	loaded, loadedSettings, err := LoadSettings(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, settings, changes := Reloaded(cur, curSettings, loaded, loadedSettings)
+
+	if cfg.Logger.Level != slog.LevelDebug || cfg.Cache.SongTTL != 7*time.Minute {
+		t.Errorf("reloadable settings are not applied: level %v, song ttl %s", cfg.Logger.Level, cfg.Cache.SongTTL)
+	}
+
+	if cfg.Server.Port != 8080 || cfg.DB.Password != "secret" {
+		t.Errorf("settings needing a restart are applied: port %d, password %q", cfg.Server.Port, cfg.DB.Password)
+	}
+
+	applied := map[string]bool{}
+	for _, c := range changes {
+		applied[c.Key] = c.Applied
+		if c.Key == "DB_PASS" && (c.Old != redacted || c.New != redacted) {
+			t.Errorf("change of DB_PASS is not redacted: %+v", c)
+		}
+	}
+
+	want := map[string]bool{"LOG_LEVEL": true, "CACHE_SONG_TTL": true, "SERVER_PORT": false, "DB_PASS": false}
+	if len(applied) != len(want) {
+		t.Errorf("changes = %+v, want %v", changes, want)
+	}
+	for key, ok := range want {
+		if v, found := applied[key]; !found || v != ok {
+			t.Errorf("change of %s: found %t, applied %t, want applied %t", key, found, v, ok)
+		}
+	}
+
+	// the ignored changes are reported again until the restart, the applied ones are not
+	_, _, changes = Reloaded(cfg, settings, loaded, loadedSettings)
+
+	if len(changes) != 2 || changes[0].Applied || changes[1].Applied {
+		t.Errorf("changes of the second reload = %+v, want SERVER_PORT and DB_PASS ignored", changes)
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// Validate checks the values which are parsed but make no sense. All errors are
+// reported at once.
+func (cfg Config) Validate() error {
+
+	var errs []error
+
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: "+format, append([]any{key}, args...)...))
+		}
+	}
+
+	port := func(key string, v int) {
+		check(v > 0 && v < 1<<16, key, "bad port %d", v)
+	}
+
+	positive := func(key string, d time.Duration) {
+		check(d > 0, key, "must be > 0, got %s", d)
+	}
+
+	notNegative := func(key string, d time.Duration) {
+		check(d >= 0, key, "must be >= 0, got %s", d)
+	}
+
+	port("SERVER_PORT", cfg.Server.Port)
+	positive("SERVER_READ_TIMEOUT", cfg.Server.ReadTimeout)
+	positive("SERVER_WRITE_TIMEOUT", cfg.Server.WriteTimeout)
+	positive("SERVER_IDLE_TIMEOUT", cfg.Server.IdleTimeout)
+	positive("SERVER_SHUTDOWN_TIMEOUT", cfg.Server.ShutdownTimeout)
+
+	if cfg.Admin.Socket == "" {
+		port("ADMIN_PORT", cfg.Admin.Port)
+		check(cfg.Admin.Port != cfg.Server.Port, "ADMIN_PORT", "must differ from SERVER_PORT")
+	}
+
+	// the DSN is used as is, so the password would be ignored
+	check(cfg.DB.DSN == "" || (cfg.DB.Password == "" && cfg.DB.PasswordFile == ""),
+		"DB_DSN", "can't be used with DB_PASS or DB_PASS_FILE, put the password into the DSN")
+
+	if cfg.DB.DSN == "" {
+		for _, v := range []struct{ key, value string }{
+			{"DB_HOST", cfg.DB.Host}, {"DB_NAME", cfg.DB.Name}, {"DB_USER", cfg.DB.User},
+		} {
+			if v.value == "" {
+				errs = append(errs, fmt.Errorf("%s %w unless DB_DSN is set", v.key, ErrRequired))
+			}
+		}
+		port("DB_PORT", cfg.DB.Port)
+		check(slices.Contains([]string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}, cfg.DB.SSLMode),
+			"DB_SSLMODE", "must be disable, allow, prefer, require, verify-ca or verify-full, got %q", cfg.DB.SSLMode)
+	}
+	positive("DB_CONNECT_TIMEOUT", cfg.DB.ConnectTimeout)
+	notNegative("DB_STATEMENT_TIMEOUT", cfg.DB.StatementTimeout)
+	check(cfg.DB.MaxOpenConns >= 0, "DB_MAX_OPEN_CONNS", "must be >= 0 (0 is unlimited)")
+	check(cfg.DB.MaxIdleConns >= 0, "DB_MAX_IDLE_CONNS", "must be >= 0")
+	check(cfg.DB.MaxOpenConns == 0 || cfg.DB.MaxIdleConns <= cfg.DB.MaxOpenConns,
+		"DB_MAX_IDLE_CONNS", "must be <= DB_MAX_OPEN_CONNS")
+	notNegative("DB_CONN_MAX_LIFETIME", cfg.DB.ConnMaxLifetime)
+	notNegative("DB_CONN_MAX_IDLE_TIME", cfg.DB.ConnMaxIdleTime)
+
+	if u, err := url.Parse(cfg.RemoteAPI.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, errors.New("REMOTE_API_URL: must be an absolute http(s) URL"))
+	}
+	positive("REMOTE_API_TIMEOUT", cfg.RemoteAPI.Timeout)
+
+	check(cfg.Cache.RemoteSize >= 0, "CACHE_REMOTE_SIZE", "must be >= 0")
+	check(cfg.Cache.SongSize >= 0, "CACHE_SONG_SIZE", "must be >= 0")
+	notNegative("CACHE_REMOTE_TTL", cfg.Cache.RemoteTTL)
+	notNegative("CACHE_REMOTE_NEGATIVE_TTL", cfg.Cache.RemoteNegativeTTL)
+	notNegative("CACHE_SONG_TTL", cfg.Cache.SongTTL)
+
+	check(slices.Contains([]string{"none", "stdout", "otlp"}, cfg.Tracing.Exporter),
+		"TRACING_EXPORTER", "must be none, stdout or otlp, got %q", cfg.Tracing.Exporter)
+
+	positive("HEALTH_TIMEOUT", cfg.Health.Timeout)
+	notNegative("HEALTH_REMOTE_TTL", cfg.Health.RemoteTTL)
+	notNegative("HEALTH_SHUTDOWN_DELAY", cfg.Health.ShutdownDelay)
+
+	check(cfg.OpenAPI.Validate || !cfg.OpenAPI.ValidateResponses,
+		"OPENAPI_VALIDATE_RESPONSES", "requires OPENAPI_VALIDATE")
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"effective-mobile-go/internal/model"
+)
+
+type apiKey struct {
+	ID        uint64     `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Roles     []string   `json:"roles"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+func newAPIKey(key model.APIKey) apiKey {
+
+	roles := key.Roles
+	if roles == nil {
+		roles = []string{} // guarantee not nil
+	}
+
+	return apiKey{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Roles:     roles,
+		CreatedAt: key.CreatedAt,
+		RevokedAt: key.RevokedAt,
+	}
+}
+
+type createAPIKeyRequest struct {
+	Name  string   `json:"name" example:"importer"`
+	Roles []string `json:"roles" example:"admin"`
+}
+
+type createAPIKeyResponse struct {
+	APIKey apiKey `json:"apiKey"`
+	Key    string `json:"key"` // shown only once
+}
+
+// createAPIKeyHandler godoc
+//
+//	@Summary		Create API key
+//	@Description	The key is returned only once, only its hash is stored. Requires admin role.
+//	@Tags			apikeys
+//	@Accept			json
+//	@Produce		json
+//	@Param			req	body		createAPIKeyRequest	true	"CreateAPIKeyRequest"
+//	@Success		200	{object}	createAPIKeyResponse
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		403	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/apikeys [post]
+func (h handler) createAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	x := newHelper("createAPIKeyHandler", w, r)
+
+	var req createAPIKeyRequest
+
+	if err := x.DecodeBody(&req); err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	x.Log().Debug("http request parsed", "name", req.Name, "roles", req.Roles)
+
+	key, secret, err := h.CreateAPIKey(x.Ctx(), req.Name, req.Roles)
+	if err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	resp := createAPIKeyResponse{
+		APIKey: newAPIKey(key),
+		Key:    secret,
+	}
+
+	x.WriteResponse(&resp)
+}
+
+type listAPIKeysResponse struct {
+	APIKeys []apiKey `json:"apiKeys"`
+}
+
+// listAPIKeysHandler godoc
+//
+//	@Summary		List API keys
+//	@Description	Including revoked ones. Requires admin role.
+//	@Tags			apikeys
+//	@Produce		json
+//	@Success		200	{object}	listAPIKeysResponse
+//	@Failure		401	{object}	problem.Details
+//	@Failure		403	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/apikeys [get]
+func (h handler) listAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	x := newHelper("listAPIKeysHandler", w, r)
+
+	list, err := h.ListAPIKeys(x.Ctx())
+	if err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	resp := listAPIKeysResponse{APIKeys: []apiKey{}} // guarantee not nil
+
+	for _, key := range list {
+		resp.APIKeys = append(resp.APIKeys, newAPIKey(key))
+	}
+
+	x.WriteResponse(&resp)
+}
+
+// revokeAPIKeyHandler godoc
+//
+//	@Summary		Revoke API key
+//	@Description	Requires admin role.
+//	@Tags			apikeys
+//	@Produce		json
+//	@Param			id	path		uint	true	"API key id"
+//	@Success		200	{object}	emptyResponse
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		403	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/apikeys/{id} [delete]
+func (h handler) revokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	x := newHelper("revokeAPIKeyHandler", w, r)
+
+	keyID, err := x.GetID()
+	if err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	x.Log().Debug("http request parsed", "keyID", keyID)
+
+	if err := h.RevokeAPIKey(x.Ctx(), keyID); err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	x.WriteResponse(&emptyResponse{})
+}
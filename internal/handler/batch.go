@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"effective-mobile-go/internal/model"
+)
+
+type batchMode string
+
+const (
+	batchAtomic     batchMode = "atomic"
+	batchBestEffort batchMode = "bestEffort"
+)
+
+type batchItemResult struct {
+	Index  int         `json:"index"`
+	ID     uint64      `json:"id,omitempty"`
+	Status string      `json:"status" enums:"ok,failed,aborted"`
+	Reason string      `json:"reason,omitempty"`
+	Song   *songDetail `json:"song,omitempty"`
+}
+
+type batchResponse struct {
+	Committed bool              `json:"committed"`
+	Results   []batchItemResult `json:"results"`
+}
+
+type batchCreateSongsRequest struct {
+	Mode  batchMode           `json:"mode,omitempty" enums:"atomic,bestEffort" default:"atomic"`
+	Songs []createSongRequest `json:"songs"`
+}
+
+// batchCreateSongsHandler godoc
+//
+//	@Summary		Create song entries in batch
+//	@Description	In atomic mode either all songs are created or none (in one transaction),
+//	@Description	in best-effort mode every song is created independently.
+//	@Tags			batch
+//	@Accept			json
+//	@Produce		json
+//	@Param			req	body		batchCreateSongsRequest	true	"BatchCreateSongsRequest"
+//	@Success		200	{object}	batchResponse
+//	@Failure		400	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/songs:batchCreate [post]
+func (h handler) batchCreateSongsHandler(w http.ResponseWriter, r *http.Request) {
+	x := newHelper("batchCreateSongsHandler", w, r)
+
+	var req batchCreateSongsRequest
+
+	if err := x.DecodeBody(&req); err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	atomic, err := x.ParseBatchMode(req.Mode)
+	if err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	songs := make([]model.SongDetail, 0, len(req.Songs))
+	for _, v := range req.Songs {
+		songs = append(songs, model.SongDetail{Name: v.Song, Group: v.Group})
+	}
+
+	x.Log().Debug("http request parsed", "mode", req.Mode, "count", len(songs))
+
+	results, committed, err := h.BatchCreateSongs(x.Ctx(), songs, atomic)
+	if err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	x.WriteResponse(newBatchResponse(results, committed))
+}
+
+type batchUpdateSongRequest struct {
+	ID uint64 `json:"id"`
+	updateSongRequest
+}
+
+type batchUpdateSongsRequest struct {
+	Mode  batchMode                `json:"mode,omitempty" enums:"atomic,bestEffort" default:"atomic"`
+	Songs []batchUpdateSongRequest `json:"songs"`
+}
+
+// batchUpdateSongsHandler godoc
+//
+//	@Summary		Update song library entries in batch
+//	@Description	In atomic mode either all songs are updated or none (in one transaction),
+//	@Description	in best-effort mode every song is updated independently.
+//	@Tags			batch
+//	@Accept			json
+//	@Produce		json
+//	@Param			req	body		batchUpdateSongsRequest	true	"BatchUpdateSongsRequest"
+//	@Success		200	{object}	batchResponse
+//	@Failure		400	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/songs:batchUpdate [patch]
+func (h handler) batchUpdateSongsHandler(w http.ResponseWriter, r *http.Request) {
+	x := newHelper("batchUpdateSongsHandler", w, r)
+
+	var req batchUpdateSongsRequest
+
+	if err := x.DecodeBody(&req); err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	atomic, err := x.ParseBatchMode(req.Mode)
+	if err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	updates := make([]model.SongUpdate, 0, len(req.Songs))
+	for i, v := range req.Songs {
+
+		if v.ID == 0 {
+
+			x.Log().Debug("song id is required")
+			x.WriteError(ErrBadRequest.WithField(fmt.Sprintf("songs[%d].id", i), "id is required"))
+			return
+		}
+
+		updates = append(updates, model.SongUpdate{
+			ID:      v.ID,
+			Release: v.Release,
+			Text:    v.Text,
+			Link:    v.Link,
+		})
+	}
+
+	x.Log().Debug("http request parsed", "mode", req.Mode, "count", len(updates))
+
+	results, committed, err := h.BatchUpdateSongs(x.Ctx(), updates, atomic)
+	if err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	x.WriteResponse(newBatchResponse(results, committed))
+}
+
+type batchDeleteSongsRequest struct {
+	Mode batchMode `json:"mode,omitempty" enums:"atomic,bestEffort" default:"atomic"`
+	IDs  []uint64  `json:"ids"`
+}
+
+// batchDeleteSongsHandler godoc
+//
+//	@Summary		Delete song library entries in batch
+//	@Description	In atomic mode either all songs are deleted or none (in one transaction),
+//	@Description	in best-effort mode every song is deleted independently.
+//	@Tags			batch
+//	@Accept			json
+//	@Produce		json
+//	@Param			req	body		batchDeleteSongsRequest	true	"BatchDeleteSongsRequest"
+//	@Success		200	{object}	batchResponse
+//	@Failure		400	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/songs:batchDelete [post]
+func (h handler) batchDeleteSongsHandler(w http.ResponseWriter, r *http.Request) {
+	x := newHelper("batchDeleteSongsHandler", w, r)
+
+	var req batchDeleteSongsRequest
+
+	if err := x.DecodeBody(&req); err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	atomic, err := x.ParseBatchMode(req.Mode)
+	if err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	x.Log().Debug("http request parsed", "mode", req.Mode, "count", len(req.IDs))
+
+	results, committed, err := h.BatchDeleteSongs(x.Ctx(), req.IDs, atomic)
+	if err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	x.WriteResponse(newBatchResponse(results, committed))
+}
+
+// ParseBatchMode returns true for atomic mode (the default).
+func (x *helper) ParseBatchMode(mode batchMode) (atomic bool, _ error) {
+	switch mode {
+	case "", batchAtomic:
+		return true, nil
+	case batchBestEffort:
+		return false, nil
+	}
+
+	x.Log().Debug("unknown batch mode", "mode", mode)
+	return false, ErrBadRequest.WithField("mode", fmt.Sprintf("mode must be %s or %s", batchAtomic, batchBestEffort))
+}
+
+func newBatchResponse(results []model.BatchResult, committed bool) *batchResponse {
+
+	resp := batchResponse{
+		Committed: committed,
+		Results:   make([]batchItemResult, 0, len(results)),
+	}
+
+	for _, v := range results {
+
+		item := batchItemResult{
+			Index:  v.Index,
+			ID:     v.ID,
+			Status: string(v.Status),
+			Reason: v.Reason,
+		}
+
+		if song := v.Song; song != nil {
+			item.Song = &songDetail{
+				ID:      song.ID,
+				Name:    song.Name,
+				Group:   song.Group,
+				Release: song.Release.String(),
+				Link:    song.Link,
+			}
+		}
+
+		resp.Results = append(resp.Results, item)
+	}
+
+	return &resp
+}
@@ -0,0 +1,328 @@
+package handler
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"effective-mobile-go/internal/model"
+)
+
+// Bind reads the request into req, a pointer to struct, and validates it. The fields are
+// read from the path (`path:"id"`), the query (`query:"limit"`) and the JSON body (the
+// body is decoded if any field has a json tag). Embedded structs are read as a part of
+// the outer one. The supported field types are strings, bools, integers, the types
+// implementing encoding.TextUnmarshaler and pointers to them; the pointers stay nil if
+// the value is absent. The query values which are empty are absent.
+//
+// The rules of the `validate` tag (separated by comma):
+//
+//	required     the value must be present and not zero
+//	min=N max=N  the length of a string (in characters) or the value of a number
+//	gt=N         the value of a number must be greater than N
+//	url          a string must be an absolute http(s) URL
+//	oneof=a b c  a string must be one of the values
+//
+// The `format` tag describes the expected value in the reason of a parse error of
+// a TextUnmarshaler ("release must be DD.MM.YYYY").
+//
+// All violations are reported at once as ErrBadRequest with the invalid fields.
+func (x *helper) Bind(req any) error {
+
+	v := reflect.ValueOf(req).Elem()
+
+	if hasBody(v.Type()) {
+		if err := x.DecodeBody(req); err != nil {
+			return err
+		}
+	}
+
+	b := binder{x: x, query: x.r.URL.Query(), bad: ErrBadRequest}
+	b.bindStruct(v)
+
+	if len(b.bad.Fields()) > 0 {
+
+		x.Log().Debug("invalid request", "fields", b.bad.Fields())
+		return b.bad
+	}
+
+	return nil
+}
+
+type binder struct {
+	x     *helper
+	query url.Values
+	bad   *model.Error
+}
+
+func (b *binder) bindStruct(v reflect.Value) {
+
+	t := v.Type()
+
+	for i := range t.NumField() {
+
+		f := t.Field(i)
+		fv := v.Field(i)
+
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			b.bindStruct(fv)
+			continue
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		var (
+			name, s string
+			present bool
+		)
+
+		switch {
+		case f.Tag.Get("path") != "":
+			name = f.Tag.Get("path")
+			s = b.x.r.PathValue(name)
+			present = s != ""
+
+		case f.Tag.Get("query") != "":
+			name = f.Tag.Get("query")
+			s = b.query.Get(name)
+			present = s != ""
+
+		case jsonName(f) != "":
+			name = jsonName(f)
+			b.validate(name, f, fv) // already decoded
+			continue
+
+		default:
+			continue
+		}
+
+		if present {
+			if err := setString(fv, s); err != nil {
+
+				b.x.Log().Debug("can't parse field", "field", name, "value", s, "error", err)
+				b.fail(name, parseReason(name, f))
+				continue
+			}
+		}
+
+		b.validate(name, f, fv)
+	}
+}
+
+func (b *binder) fail(name, reason string) {
+	b.bad = b.bad.WithField(name, reason)
+}
+
+func (b *binder) validate(name string, f reflect.StructField, v reflect.Value) {
+
+	tag := f.Tag.Get("validate")
+	if tag == "" {
+		return
+	}
+
+	rules := strings.Split(tag, ",")
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			if slices.Contains(rules, "required") {
+				b.fail(name, name+" is required")
+			}
+			return
+		}
+		v = v.Elem()
+	}
+
+	for _, rule := range rules {
+
+		rule, arg, _ := strings.Cut(rule, "=")
+
+		var reason string
+
+		switch rule {
+		case "required":
+			if v.IsZero() {
+				reason = name + " is required"
+			}
+
+		case "min", "max", "gt":
+			reason = checkBound(name, rule, mustAtoi(arg), v)
+
+		case "url":
+			if !isURL(v.String()) {
+				reason = name + " must be an absolute http(s) URL"
+			}
+
+		case "oneof":
+			if s := v.String(); s != "" && !slices.Contains(strings.Fields(arg), s) {
+				reason = fmt.Sprintf("%s must be one of %s", name, strings.Join(strings.Fields(arg), ", "))
+			}
+
+		default:
+			panic(fmt.Sprintf("handler: unknown validation rule %q of %s", rule, name))
+		}
+
+		if reason != "" {
+			b.fail(name, reason)
+			return // one reason per field is enough
+		}
+	}
+}
+
+func checkBound(name, rule string, n int, v reflect.Value) string {
+
+	if v.Kind() == reflect.String {
+
+		if v.Len() == 0 {
+			return "" // absent, see required
+		}
+
+		l := utf8.RuneCountInString(v.String())
+		switch {
+		case rule == "min" && l < n:
+			return fmt.Sprintf("%s must be at least %d characters", name, n)
+		case rule == "max" && l > n:
+			return fmt.Sprintf("%s must be at most %d characters", name, n)
+		}
+		return ""
+	}
+
+	var x int64
+	switch {
+	case v.CanInt():
+		x = v.Int()
+	case v.CanUint():
+		x = int64(min(v.Uint(), 1<<63-1))
+	default:
+		panic(fmt.Sprintf("handler: rule %s can't be applied to %s of %s", rule, name, v.Type()))
+	}
+
+	switch {
+	case rule == "min" && x < int64(n):
+		return fmt.Sprintf("%s must be >= %d", name, n)
+	case rule == "max" && x > int64(n):
+		return fmt.Sprintf("%s must be <= %d", name, n)
+	case rule == "gt" && x <= int64(n):
+		return fmt.Sprintf("%s must be > %d", name, n)
+	}
+	return ""
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// setString parses s into v. If v is a nil pointer, the value is allocated.
+func setString(v reflect.Value, s string) error {
+
+	if v.Kind() == reflect.Pointer {
+		p := reflect.New(v.Type().Elem())
+		if err := setString(p.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+
+	if v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+
+	case reflect.Bool:
+		x, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(x)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(x)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(x)
+
+	default:
+		panic(fmt.Sprintf("handler: can't bind %s", v.Type()))
+	}
+
+	return nil
+}
+
+func parseReason(name string, f reflect.StructField) string {
+
+	if format := f.Tag.Get("format"); format != "" {
+		return name + " must be " + format
+	}
+
+	t := f.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return name + " must be true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return name + " must be an integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return name + " must be an unsigned integer"
+	}
+
+	return name + " is invalid"
+}
+
+func hasBody(t reflect.Type) bool {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if hasBody(f.Type) {
+				return true
+			}
+			continue
+		}
+		if f.IsExported() && jsonName(f) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+func isURL(s string) bool {
+	if s == "" {
+		return true // absent, see required
+	}
+	u, err := url.ParseRequestURI(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func mustAtoi(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		panic(fmt.Sprintf("handler: bad validation rule argument %q", s))
+	}
+	return n
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"effective-mobile-go/internal/model"
+)
+
+func Test_helper_Bind(t *testing.T) {
+
+	type bodyRequest struct {
+		Name string  `json:"name" validate:"required,max=5"`
+		Link *string `json:"link" validate:"url"`
+		Mode string  `json:"mode" validate:"oneof=a b"`
+	}
+
+	date := func(s string) *model.Date {
+		d, _ := model.ParseDate(s)
+		return &d
+	}
+	ptr := func(v uint64) *uint64 { return &v }
+	lang := langParam("pt-br")
+
+	tests := []struct {
+		name       string
+		url        string
+		id         string // path value
+		body       string
+		got, want  any
+		wantFields []model.FieldError
+	}{
+		{
+			name: "query",
+			url:  "/songs?release=02.01.2006&lang=pt-BR&offset=10&limit=5&text=",
+			got:  &songFiltersQuery{},
+			want: &songFiltersQuery{Release: date("02.01.2006"), Lang: &lang, Offset: ptr(10), Limit: ptr(5)},
+		},
+		{
+			name: "all query violations",
+			url:  "/songs?release=2006-01-02&lang=e_n&offset=-1&limit=0&group=" + strings.Repeat("й", 51),
+			got:  &songFiltersQuery{},
+			wantFields: []model.FieldError{
+				{Field: "group", Reason: "group must be at most 50 characters"},
+				{Field: "release", Reason: "release must be DD.MM.YYYY"},
+				{Field: "lang", Reason: "lang must be a language code like en or pt-BR"},
+				{Field: "offset", Reason: "offset must be an unsigned integer"},
+				{Field: "limit", Reason: "limit must be > 0"},
+			},
+		},
+		{
+			name: "path and embedded struct",
+			url:  "/songs/7/text?sideBySide=1&format=NDJSON",
+			id:   "7",
+			got: &struct {
+				getSongTextRequest
+				exportSongsQuery
+			}{},
+			want: &struct {
+				getSongTextRequest
+				exportSongsQuery
+			}{
+				getSongTextRequest{ID: 7, SideBySide: true},
+				exportSongsQuery{Format: "ndjson"},
+			},
+		},
+		{
+			name: "path violations",
+			url:  "/songs/x/text?sideBySide=maybe",
+			id:   "x",
+			got:  &getSongTextRequest{},
+			wantFields: []model.FieldError{
+				{Field: "id", Reason: "id must be an unsigned integer"},
+				{Field: "sideBySide", Reason: "sideBySide must be true or false"},
+			},
+		},
+		{
+			name: "body",
+			url:  "/songs",
+			body: `{"name":"Muse","link":"https://example.com/x","mode":"b"}`,
+			got:  &bodyRequest{},
+			want: &bodyRequest{Name: "Muse", Link: func() *string { s := "https://example.com/x"; return &s }(), Mode: "b"},
+		},
+		{
+			name: "all body violations",
+			url:  "/songs",
+			body: `{"name":"","link":"example.com","mode":"c"}`,
+			got:  &bodyRequest{},
+			wantFields: []model.FieldError{
+				{Field: "name", Reason: "name is required"},
+				{Field: "link", Reason: "link must be an absolute http(s) URL"},
+				{Field: "mode", Reason: "mode must be one of a, b"},
+			},
+		},
+		{
+			name: "too long body field",
+			url:  "/songs",
+			body: `{"name":"Muse Muse"}`,
+			got:  &bodyRequest{},
+			wantFields: []model.FieldError{
+				{Field: "name", Reason: "name must be at most 5 characters"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "http://localhost"+tt.url, strings.NewReader(tt.body))
+			if tt.id != "" {
+				r.SetPathValue("id", tt.id)
+			}
+
+			err := newHelper("test", w, r).Bind(tt.got)
+
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("err = %v", err)
+				}
+				if !reflect.DeepEqual(tt.got, tt.want) {
+					t.Fatalf("got = %+v, want %+v", tt.got, tt.want)
+				}
+				return
+			}
+
+			var got *model.Error
+			if !errors.As(err, &got) || !errors.Is(err, model.ErrBadRequest) {
+				t.Fatalf("err = %v, want bad request", err)
+			}
+
+			if !reflect.DeepEqual(got.Fields(), tt.wantFields) {
+				t.Fatalf("fields = %+v, want %+v", got.Fields(), tt.wantFields)
+			}
+		})
+	}
+}
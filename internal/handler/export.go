@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"time"
+
+	"effective-mobile-go/internal/songio"
+)
+
+type exportSongsQuery struct {
+	songFiltersQuery
+	Format   songio.Format `query:"format" format:"csv, ndjson, json or xlsx"`
+	WithText bool          `query:"withText"`
+}
+
+// exportSongsHandler godoc
+//
+//	@Summary		Export song library
+//	@Description	Streams songs matching the filters in the format. The columns are id, group, song,
+//	@Description	release, link and, if withText, text. The response is gzipped if the client accepts it.
+//	@Tags			songs
+//	@Produce		plain
+//	@Param			format		query		string	false	"Output format (csv by default)"	Enums(csv, ndjson, json, xlsx)
+//	@Param			withText	query		bool	false	"Include song text"
+//	@Param			song		query		string	false	"Song name"
+//	@Param			group		query		string	false	"Song group name"
+//	@Param			release		query		string	false	"Song release date (example: 02.01.2006)"
+//	@Param			text		query		string	false	"Song text should contain it"
+//	@Param			link		query		string	false	"Song link"
+//	@Param			lang		query		string	false	"Detected song text language (example: en)"
+//	@Param			offset		query		uint64	false	"Offeset"
+//	@Param			limit		query		uint64	false	"Limit"
+//	@Success		200			{file}		file
+//	@Failure		400			{object}	problem.Details
+//	@Failure		500			{object}	problem.Details
+//	@Router			/songs/export [get]
+func (h handler) exportSongsHandler(w http.ResponseWriter, r *http.Request) {
+	x := newHelper("exportSongsHandler", w, r)
+
+	q := exportSongsQuery{Format: songio.CSV}
+
+	if err := x.Bind(&q); err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	req, format, withText := q.filters(), q.Format, q.WithText
+
+	useGzip := acceptsGzip(r.Header.Get("Accept-Encoding"))
+
+	// large exports take longer than the server write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		x.Log().Debug("can't reset write deadline", "error", err)
+	}
+
+	x.Log().Debug("http request parsed", "req", req, "format", format, "withText", withText, "gzip", useGzip)
+
+	// The headers are sent with the first byte of the body, so that we still can respond
+	// with an error if the export fails before any song is written.
+	out := &lazyHeaderWriter{w: w, header: func(h http.Header) {
+		h.Set("Content-Type", format.ContentType())
+		h.Set("Content-Disposition", `attachment; filename="songs.`+string(format)+`"`)
+		h.Add("Vary", "Accept-Encoding")
+		if useGzip {
+			h.Set("Content-Encoding", "gzip")
+		}
+	}}
+
+	var (
+		zw   *gzip.Writer
+		dest songio.Writer
+		err  error
+	)
+
+	if useGzip {
+		zw = gzip.NewWriter(out)
+		dest, err = songio.NewWriter(format, zw, withText)
+	} else {
+		dest, err = songio.NewWriter(format, out, withText)
+	}
+
+	if err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	err = h.ExportSongs(x.Ctx(), req, withText, dest.Write)
+
+	if err == nil {
+		err = dest.Close()
+	}
+
+	if err == nil && zw != nil {
+		err = zw.Close()
+	}
+
+	if err != nil {
+
+		if !out.started {
+			x.WriteError(err)
+			return
+		}
+
+		// too late to report the error, just break the response
+		x.Log().Error("export interrupted", "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			return strings.TrimSpace(params) != "q=0"
+		}
+	}
+	return false
+}
+
+type lazyHeaderWriter struct {
+	w       http.ResponseWriter
+	header  func(http.Header)
+	started bool
+}
+
+func (lw *lazyHeaderWriter) Write(b []byte) (int, error) {
+	if !lw.started {
+		lw.started = true
+		lw.header(lw.w.Header())
+	}
+	return lw.w.Write(b)
+}
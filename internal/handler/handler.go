@@ -4,13 +4,18 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
-	"strconv"
 
+	"effective-mobile-go/internal/metrics"
 	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/ratelimit"
+	"effective-mobile-go/internal/songio"
+	"effective-mobile-go/internal/tracing"
 )
 
 var (
 	ErrBadRequest    = model.ErrBadRequest
+	ErrUnauthorized  = model.ErrUnauthorized
+	ErrForbidden     = model.ErrForbidden
 	ErrNotFound      = model.ErrNotFound
 	ErrInternalError = model.ErrInternalError
 )
@@ -19,40 +24,74 @@ type Service interface {
 	CreateSong(context.Context, model.SongDetail) (model.SongDetail, error)
 	ListSongs(context.Context, model.SongFilters) ([]model.SongDetail, error)
 	GetSong(_ context.Context, songID uint64) (model.SongDetail, error)
-	GetSongText(context.Context, model.GetSongTextRequest) ([]string, error)
+	GetSongText(context.Context, model.GetSongTextRequest) (model.SongText, error)
 	UpdateSong(context.Context, model.SongUpdate) (model.SongDetail, error)
 	DeleteSong(_ context.Context, songID uint64) error
+	ListSongLyrics(_ context.Context, songID uint64) ([]model.SongLyrics, error)
+	PutSongLyrics(context.Context, model.SongLyrics) (model.SongLyrics, error)
+	DeleteSongLyrics(_ context.Context, songID uint64, lang string) error
+	GetSongStats(_ context.Context, songID uint64) (model.SongStats, error)
+	ImportSongs(context.Context, songio.Reader, model.ImportOptions) ([]model.ImportResult, error)
+	ExportSongs(_ context.Context, _ model.SongFilters, withText bool, fn func(model.SongDetail) error) error
+	BatchCreateSongs(_ context.Context, _ []model.SongDetail, atomic bool) ([]model.BatchResult, bool, error)
+	BatchUpdateSongs(_ context.Context, _ []model.SongUpdate, atomic bool) ([]model.BatchResult, bool, error)
+	BatchDeleteSongs(_ context.Context, songIDs []uint64, atomic bool) ([]model.BatchResult, bool, error)
+	CreateAPIKey(_ context.Context, name string, roles []string) (model.APIKey, string, error)
+	ListAPIKeys(context.Context) ([]model.APIKey, error)
+	RevokeAPIKey(_ context.Context, keyID uint64) error
 }
 
-func New(service Service) http.Handler {
+// Limiter limits the rate of requests of the route class.
+type Limiter interface {
+	Limit(ratelimit.Class, http.Handler) http.Handler
+}
 
-	mux := http.NewServeMux()
+// New returns the API handler. The limiter may be nil if the requests are not limited.
+func New(service Service, limiter Limiter) http.Handler {
 
-	mux.Handle("GET /ping", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		x := newHelper("ping", w, r)
-		x.Log().Debug("knock-knock", slog.String("remoteAddr", r.RemoteAddr))
-		x.WriteResponse("pong")
-	}))
+	mux := http.NewServeMux()
 
 	h := handler{service}
 
-	mux.Handle("GET    /songs", http.HandlerFunc(h.listSongsHandler))
-	mux.Handle("POST   /songs", http.HandlerFunc(h.createSongHandler))
+	mux.Handle("GET /ping", instrument("GET /ping", http.HandlerFunc(h.pingHandler)))
 
-	mux.Handle("GET    /songs/{id}", http.HandlerFunc(h.getSongHandler))
-	mux.Handle("GET    /songs/{id}/text", http.HandlerFunc(h.getSongTextHandler))
-	mux.Handle("POST   /songs/{id}", http.HandlerFunc(h.updateSongHandler))
-	mux.Handle("DELETE /songs/{id}", http.HandlerFunc(h.deleteSongHandler))
+	for _, route := range h.routes() {
+
+		var next http.Handler = authorize(route.role, route.handler)
+		if limiter != nil {
+			next = limiter.Limit(route.class, next)
+		}
+
+		mux.Handle(route.pattern, instrument(route.pattern, next))
+	}
 
 	return mux
 }
 
+// instrument reports the route pattern of the request to metrics and traces.
+func instrument(pattern string, h http.Handler) http.Handler {
+	return metrics.Route(pattern, tracing.Route(pattern, h))
+}
+
 type handler struct {
 	Service
 }
 
 type emptyResponse struct{}
 
+// pingHandler godoc
+//
+//	@Summary	Check the server is up (no authentication)
+//	@Tags		misc
+//	@Produce	json
+//	@Success	200	{string}	string	"pong"
+//	@Router		/ping [get]
+func (h handler) pingHandler(w http.ResponseWriter, r *http.Request) {
+	x := newHelper("ping", w, r)
+	x.Log().Debug("knock-knock", slog.String("remoteAddr", r.RemoteAddr))
+	x.WriteResponse("pong")
+}
+
 type songDetail struct {
 	ID      uint64 `json:"id"`
 	Name    string `json:"name,omitempty"`
@@ -63,8 +102,8 @@ type songDetail struct {
 }
 
 type createSongRequest struct {
-	Song  string `json:"song" example:"Supermassive Black Hole"`
-	Group string `json:"group" example:"Muse"`
+	Song  string `json:"song" validate:"required,max=50" example:"Supermassive Black Hole"`
+	Group string `json:"group" validate:"required,max=50" example:"Muse"`
 }
 
 type createSongResponse struct {
@@ -79,34 +118,20 @@ type createSongResponse struct {
 //	@Produce	json
 //	@Param		req	body		createSongRequest	true	"CreateSongRequest"
 //	@Success	200	{object}	createSongResponse
-//	@Failure	400	{object}	errorResponse
-//	@Failure	404	{object}	errorResponse
-//	@Failure	500	{object}	errorResponse
+//	@Failure	400	{object}	problem.Details
+//	@Failure	404	{object}	problem.Details
+//	@Failure	500	{object}	problem.Details
 //	@Router		/songs [post]
 func (h handler) createSongHandler(w http.ResponseWriter, r *http.Request) {
 	x := newHelper("createSongHandler", w, r)
 
 	var req createSongRequest
 
-	if err := x.DecodeBody(&req); err != nil {
+	if err := x.Bind(&req); err != nil {
 		x.WriteError(err)
 		return
 	}
 
-	if req.Song == "" {
-
-		x.Log().Debug("song is required")
-		x.WriteError(ErrBadRequest)
-		return
-	}
-
-	if req.Group == "" {
-
-		x.Log().Debug("group is required")
-		x.WriteError(ErrBadRequest)
-		return
-	}
-
 	song := model.SongDetail{
 		Name:  req.Song,
 		Group: req.Group,
@@ -133,6 +158,31 @@ func (h handler) createSongHandler(w http.ResponseWriter, r *http.Request) {
 	x.WriteResponse(&resp)
 }
 
+// songFiltersQuery is the query of the song filters (used by list and export).
+type songFiltersQuery struct {
+	Song    *string     `query:"song" validate:"max=50"`
+	Group   *string     `query:"group" validate:"max=50"`
+	Release *model.Date `query:"release" format:"DD.MM.YYYY"`
+	Text    *string     `query:"text"`
+	Link    *string     `query:"link" validate:"max=256"`
+	Lang    *langParam  `query:"lang" format:"a language code like en or pt-BR"`
+	Offset  *uint64     `query:"offset"`
+	Limit   *uint64     `query:"limit" validate:"gt=0"`
+}
+
+func (q songFiltersQuery) filters() model.SongFilters {
+	return model.SongFilters{
+		Name:    q.Song,
+		Group:   q.Group,
+		Release: q.Release,
+		Text:    q.Text,
+		Link:    q.Link,
+		Lang:    (*string)(q.Lang),
+		Offset:  q.Offset,
+		Limit:   q.Limit,
+	}
+}
+
 type listSongsResponse struct {
 	Songs []songDetail `json:"songs"`
 }
@@ -147,73 +197,25 @@ type listSongsResponse struct {
 //	@Param		release	query		string	false	"Song release date (example: 02.01.2006)"
 //	@Param		text	query		string	false	"Song text should contain it"
 //	@Param		link	query		string	false	"Song link"
+//	@Param		lang	query		string	false	"Detected song text language (example: en)"
 //	@Param		offset	query		uint64	false	"Offeset"
 //	@Param		limit	query		uint64	false	"Limit"
 //	@Success	200		{object}	listSongsResponse
-//	@Failure	400		{object}	errorResponse
-//	@Failure	404		{object}	errorResponse
-//	@Failure	500		{object}	errorResponse
+//	@Failure	400		{object}	problem.Details
+//	@Failure	404		{object}	problem.Details
+//	@Failure	500		{object}	problem.Details
 //	@Router		/songs [get]
 func (h handler) listSongsHandler(w http.ResponseWriter, r *http.Request) {
 	x := newHelper("listSongsHandler", w, r)
 
-	var req model.SongFilters
-	q := r.URL.Query()
-
-	if s := q.Get("song"); s != "" {
-		req.Name = &s
-	}
-
-	if s := q.Get("group"); s != "" {
-		req.Group = &s
-	}
-
-	if s := q.Get("release"); s != "" {
-		v, err := model.ParseDate(s)
-		if err != nil {
-
-			x.Log().Debug("can't parse release date", "error", err, "release", s)
-			x.WriteError(ErrBadRequest)
-			return
-		}
-		req.Release = &v
-	}
-
-	if s := q.Get("text"); s != "" {
-		req.Text = &s
-	}
-
-	if s := q.Get("link"); s != "" {
-		req.Link = &s
-	}
+	var q songFiltersQuery
 
-	if s := q.Get("offset"); s != "" {
-		v, err := strconv.ParseUint(s, 10, 64)
-		if err != nil {
-
-			x.Log().Debug("can't parse offset", "error", err)
-			x.WriteError(ErrBadRequest)
-			return
-		}
-		req.Offset = &v
+	if err := x.Bind(&q); err != nil {
+		x.WriteError(err)
+		return
 	}
 
-	if s := q.Get("limit"); s != "" {
-		v, err := strconv.ParseUint(s, 10, 64)
-		if err != nil {
-
-			x.Log().Debug("can't parse limit", "error", err)
-			x.WriteError(ErrBadRequest)
-			return
-		}
-		if v == 0 {
-
-			x.Log().Debug("limit can not be 0", "error", err)
-			x.WriteError(ErrBadRequest)
-			return
-		}
-		req.Limit = &v
-	}
+	req := q.filters()
 
 	x.Log().Debug("http request parsed", "req", req)
 
@@ -250,9 +252,9 @@ type getSongResponse struct {
 //	@Produce	json
 //	@Param		id	path		uint64	true	"Song id"
 //	@Success	200	{object}	getSongResponse
-//	@Failure	400	{object}	errorResponse
-//	@Failure	404	{object}	errorResponse
-//	@Failure	500	{object}	errorResponse
+//	@Failure	400	{object}	problem.Details
+//	@Failure	404	{object}	problem.Details
+//	@Failure	500	{object}	problem.Details
 //	@Router		/songs/{id} [get]
 func (h handler) getSongHandler(w http.ResponseWriter, r *http.Request) {
 	x := newHelper("getSongHandler", w, r)
@@ -284,78 +286,84 @@ func (h handler) getSongHandler(w http.ResponseWriter, r *http.Request) {
 	x.WriteResponse(&resp)
 }
 
+type getSongTextRequest struct {
+	ID         uint64    `path:"id"`
+	Lang       langParam `query:"lang" format:"a language code like en or pt-BR"`
+	SideBySide bool      `query:"sideBySide"`
+	Offset     *uint64   `query:"offset"`
+	Limit      *uint64   `query:"limit" validate:"gt=0"`
+}
+
 type getSongTextResponse struct {
-	Verses []string `json:"verses"`
+	Lang       string   `json:"lang,omitempty"`
+	Original   bool     `json:"original"`
+	Translator string   `json:"translator,omitempty"`
+	Verses     []string `json:"verses"`
+
+	// OriginalVerses are aligned with Verses, for sideBySide only
+	OriginalVerses []string `json:"originalVerses,omitempty"`
 }
 
 // getSongTextHandler godoc
 //
-//	@Summary	Get song verses text
-//	@Tags		songs
-//	@Produce	json
-//	@Param		id		path		uint	true	"Song id"
-//	@Param		offset	query		uint64	false	"Offeset"
-//	@Param		limit	query		uint64	false	"Limit"
-//	@Success	200		{object}	getSongTextResponse
-//	@Failure	400		{object}	errorResponse
-//	@Failure	404		{object}	errorResponse
-//	@Failure	500		{object}	errorResponse
-//	@Router		/songs/{id}/text [get]
+//	@Summary		Get song verses text
+//	@Description	The language is chosen by the lang param, then by the Accept-Language header.
+//	@Description	The original is returned if nothing matches Accept-Language.
+//	@Tags			songs
+//	@Produce		json
+//	@Param			id				path		uint	true	"Song id"
+//	@Param			lang			query		string	false	"Language code (example: en, pt-BR)"
+//	@Param			sideBySide		query		bool	false	"Align translated verses with the original ones"
+//	@Param			offset			query		uint64	false	"Offeset"
+//	@Param			limit			query		uint64	false	"Limit"
+//	@Param			Accept-Language	header		string	false	"Fallback for lang"
+//	@Success		200				{object}	getSongTextResponse
+//	@Failure		400				{object}	problem.Details
+//	@Failure		404				{object}	problem.Details
+//	@Failure		500				{object}	problem.Details
+//	@Router			/songs/{id}/text [get]
 func (h handler) getSongTextHandler(w http.ResponseWriter, r *http.Request) {
-	x := newHelper("getSongHandler", w, r)
-
-	x.Log().Debug("getSongTextHandler")
+	x := newHelper("getSongTextHandler", w, r)
 
-	var req model.GetSongTextRequest
-	q := r.URL.Query()
+	var q getSongTextRequest
 
-	{
-		v, err := x.GetID()
-		if err != nil {
-			x.WriteError(err)
-			return
-		}
-		req.ID = v
+	if err := x.Bind(&q); err != nil {
+		x.WriteError(err)
+		return
 	}
 
-	if s := q.Get("offset"); s != "" {
-		v, err := strconv.ParseUint(s, 10, 64)
-		if err != nil {
-
-			x.Log().Debug("can't parse offset", "error", err)
-			x.WriteError(ErrBadRequest)
-			return
-		}
-		req.Offset = &v
+	req := model.GetSongTextRequest{
+		ID:         q.ID,
+		Lang:       string(q.Lang),
+		SideBySide: q.SideBySide,
+		Offset:     q.Offset,
+		Limit:      q.Limit,
 	}
 
-	if s := q.Get("limit"); s != "" {
-		v, err := strconv.ParseUint(s, 10, 64)
-		if err != nil {
-
-			x.Log().Debug("can't parse limit", "error", err)
-			x.WriteError(ErrBadRequest)
-			return
-		}
-		if v == 0 {
-
-			x.Log().Debug("limit can not be 0")
-			x.WriteError(ErrBadRequest)
-			return
-		}
-		req.Limit = &v
-	}
+	req.AcceptLanguages = parseAcceptLanguage(r.Header.Get("Accept-Language"))
 
 	x.Log().Debug("http request parsed", "req", req)
 
-	verses, err := h.GetSongText(x.Ctx(), req)
+	text, err := h.GetSongText(x.Ctx(), req)
 	if err != nil {
 		x.WriteError(err)
 		return
 	}
 
 	resp := getSongTextResponse{
-		Verses: verses,
+		Lang:           text.Lang,
+		Original:       text.Original,
+		Translator:     text.Translator,
+		Verses:         text.Verses,
+		OriginalVerses: text.OriginalVerses,
+	}
+
+	if resp.Verses == nil {
+		resp.Verses = []string{} // guarantee not nil
+	}
+
+	if text.Lang != "" {
+		w.Header().Set("Content-Language", text.Lang)
 	}
 
 	x.WriteResponse(&resp)
@@ -364,7 +372,7 @@ func (h handler) getSongTextHandler(w http.ResponseWriter, r *http.Request) {
 type updateSongRequest struct {
 	Release *model.Date `json:"release,omitempty" swaggertype:"string" example:"02.01.2006"`
 	Text    *string     `json:"text,omitempty"`
-	Link    *string     `json:"link,omitempty"`
+	Link    *string     `json:"link,omitempty" validate:"url,max=256"`
 }
 
 type updateSongResponse struct {
@@ -379,9 +387,9 @@ type updateSongResponse struct {
 //	@Param		id	path		uint				true	"Song id"
 //	@Param		req	body		updateSongRequest	true	"UpdateSongRequest"
 //	@Success	200	{object}	updateSongResponse
-//	@Failure	400	{object}	errorResponse
-//	@Failure	404	{object}	errorResponse
-//	@Failure	500	{object}	errorResponse
+//	@Failure	400	{object}	problem.Details
+//	@Failure	404	{object}	problem.Details
+//	@Failure	500	{object}	problem.Details
 //	@Router		/songs/{id} [post]
 func (h handler) updateSongHandler(w http.ResponseWriter, r *http.Request) {
 	x := newHelper("updateSongHandler", w, r)
@@ -394,7 +402,7 @@ func (h handler) updateSongHandler(w http.ResponseWriter, r *http.Request) {
 
 	var req updateSongRequest
 
-	if err := x.DecodeBody(&req); err != nil {
+	if err := x.Bind(&req); err != nil {
 		x.WriteError(err)
 		return
 	}
@@ -434,9 +442,9 @@ func (h handler) updateSongHandler(w http.ResponseWriter, r *http.Request) {
 //	@Produce	json
 //	@Param		id	path		uint	true	"Song id"
 //	@Success	200	{object}	emptyResponse
-//	@Failure	400	{object}	errorResponse
-//	@Failure	404	{object}	errorResponse
-//	@Failure	500	{object}	errorResponse
+//	@Failure	400	{object}	problem.Details
+//	@Failure	404	{object}	problem.Details
+//	@Failure	500	{object}	problem.Details
 //	@Router		/songs/{id} [delete]
 func (h handler) deleteSongHandler(w http.ResponseWriter, r *http.Request) {
 	x := newHelper("deleteSongHandler", w, r)
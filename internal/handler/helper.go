@@ -8,14 +8,20 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 
+	"effective-mobile-go/internal/auth"
 	"effective-mobile-go/internal/logger"
 	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/problem"
 )
 
 const loggerGroup = "handler"
 
+const reasonLang = "lang must be a language code like en or pt-BR"
+
 type helper struct {
 	op  string
 	w   http.ResponseWriter
@@ -46,42 +52,29 @@ func (x *helper) Ctx() context.Context {
 	return x.r.Context()
 }
 
-type errorResponse struct {
-	Error httpError `json:"error,omitempty"`
-}
+// WriteError writes the model.Error found in the chain as problem details (or in the
+// legacy format, see the problem package). Any other error is written as internal one.
+func (x *helper) WriteError(err error) {
+
+	if err := problem.Write(x.w, x.r, x.HTTPError(err)); err != nil {
 
-type httpError struct {
-	Code    int    `json:"code,omitempty"`
-	Message string `json:"message,omitempty"`
+		x.Log().Error("can't write response", "error", err)
+	}
 }
 
-func (x *helper) WriteError(err error) {
+// HTTPError returns the model.Error found in the chain. Any other error is logged and
+// replaced by the internal one.
+func (x *helper) HTTPError(err error) *model.Error {
 
 	var httpErr *model.Error
-	var resp errorResponse
 
-	if errors.As(err, &httpErr) {
-
-		resp = errorResponse{
-			Error: httpError{
-				Code:    httpErr.Code(),
-				Message: httpErr.Error(),
-			},
-		}
-	} else {
+	if !errors.As(err, &httpErr) {
 
 		x.Log().Error("unhandled error has been detected", "error", err, "errorType", fmt.Sprintf("%T", err), "errorDetail", fmt.Sprintf("%+v", err))
-		resp = errorResponse{
-			httpError{
-				Code:    http.StatusInternalServerError,
-				Message: http.StatusText(http.StatusInternalServerError),
-			},
-		}
+		httpErr = ErrInternalError
 	}
 
-	x.w.Header().Add("content-type", "application/json") // XXX it must be before WriteHeader
-	x.w.WriteHeader(resp.Error.Code)
-	x.WriteResponse(&resp)
+	return httpErr
 }
 
 func (x *helper) WriteResponse(resp any) {
@@ -94,6 +87,25 @@ func (x *helper) WriteResponse(resp any) {
 	}
 }
 
+// RequireRole checks that the caller has the role (or a higher one). Anonymous callers
+// are asked to authenticate, the authenticated ones are forbidden.
+func (x *helper) RequireRole(role string) error {
+
+	p, ok := auth.PrincipalFromContext(x.Ctx())
+	if ok && p.HasRole(role) {
+		return nil
+	}
+
+	if !ok || p.Method == model.AuthAnonymous {
+
+		x.Log().Debug("anonymous caller has no role", "role", role)
+		return ErrUnauthorized
+	}
+
+	x.Log().Debug("caller has no role", "role", role, "principal", p.Name, "roles", p.Roles)
+	return ErrForbidden
+}
+
 func (x *helper) GetID() (uint64, error) {
 
 	s := x.r.PathValue("id")
@@ -107,7 +119,7 @@ func (x *helper) GetID() (uint64, error) {
 	if err != nil {
 
 		x.Log().Debug("can't parse ID", "error", err, "path", x.r.URL.Path)
-		return 0, ErrBadRequest
+		return 0, ErrBadRequest.WithField("id", "id must be an unsigned integer")
 	}
 
 	return v, nil
@@ -125,8 +137,97 @@ func (x *helper) DecodeBody(req any) error {
 	if err := json.Unmarshal(body, &req); err != nil {
 
 		x.Log().Debug("can't parse body", "error", err, "body", body)
-		return ErrBadRequest
+
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return ErrBadRequest.WithField(typeErr.Field, fmt.Sprintf("%s must be %s", typeErr.Field, typeErr.Type))
+		}
+
+		return ErrBadRequest.WithDetail("can't parse body: " + err.Error())
+	}
+
+	return nil
+}
+
+func (x *helper) GetLang() (string, error) {
+
+	s := x.r.PathValue("lang")
+	if s == "" {
+
+		x.Log().Error("no lang in the path", "path", x.r.URL.Path)
+		return "", ErrInternalError
 	}
 
+	v, err := model.ParseLang(s)
+	if err != nil {
+
+		x.Log().Debug("can't parse lang", "error", err, "path", x.r.URL.Path)
+		return "", ErrBadRequest.WithField("lang", reasonLang)
+	}
+
+	return v, nil
+}
+
+// langParam is the language code bound by Bind, see model.ParseLang.
+type langParam string
+
+func (l *langParam) UnmarshalText(b []byte) error {
+	v, err := model.ParseLang(string(b))
+	if err != nil {
+		return err
+	}
+	*l = langParam(v)
 	return nil
 }
+
+// parseAcceptLanguage returns languages of the Accept-Language header ordered by
+// quality. Invalid entries and entries with q=0 are skipped.
+func parseAcceptLanguage(header string) []string {
+
+	type entry struct {
+		lang string
+		q    float64
+	}
+
+	var entries []entry
+
+	for _, part := range strings.Split(header, ",") {
+
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang = strings.TrimSpace(lang)
+
+		q := 1.0
+		if s, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				continue
+			}
+			q = v
+		}
+
+		if q <= 0 {
+			continue
+		}
+
+		if lang != "*" {
+			v, err := model.ParseLang(lang)
+			if err != nil {
+				continue
+			}
+			lang = v
+		}
+
+		entries = append(entries, entry{lang, q})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].q > entries[j].q
+	})
+
+	var langs []string
+	for _, e := range entries {
+		langs = append(langs, e.lang)
+	}
+
+	return langs
+}
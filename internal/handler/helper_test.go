@@ -2,8 +2,11 @@ package handler
 
 import (
 	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/problem"
+	"effective-mobile-go/internal/reqinfo"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
@@ -67,87 +70,187 @@ func Test_helper_WriteResponse(t *testing.T) {
 
 func Test_helper_WriteError(t *testing.T) {
 	type args struct {
-		err error
+		err       error
+		requestID string
 	}
 	tests := []struct {
 		name string
 		args
-		want errorResponse
+		want problem.Details
 	}{
 		{
 			"unknown error",
-			args{errors.New("unknown error")},
-			errorResponse{
-				Error: httpError{
-					Code:    http.StatusInternalServerError,
-					Message: http.StatusText(http.StatusInternalServerError),
-				},
+			args{errors.New("unknown error"), ""},
+			problem.Details{
+				Type:     "about:blank",
+				Title:    "Internal Server Error",
+				Status:   http.StatusInternalServerError,
+				Instance: "/test",
 			},
 		},
 		{
 			"model.ErrNotFound",
-			args{model.ErrNotFound},
-			errorResponse{
-				Error: httpError{
-					Code:    model.ErrNotFound.Code(),
-					Message: model.ErrNotFound.Error(),
-				},
+			args{model.ErrNotFound, ""},
+			problem.Details{
+				Type:     "about:blank",
+				Title:    "Not Found",
+				Status:   http.StatusNotFound,
+				Instance: "/test",
 			},
 		},
 		{
-			"model.ErrBadRequest",
-			args{model.ErrBadRequest},
-			errorResponse{
-				Error: httpError{
-					Code:    model.ErrBadRequest.Code(),
-					Message: model.ErrBadRequest.Error(),
-				},
+			"wrapped model.ErrInternalError",
+			args{fmt.Errorf("%w: db is down", model.ErrInternalError), ""},
+			problem.Details{
+				Type:     "about:blank",
+				Title:    "Internal Server Error",
+				Status:   http.StatusInternalServerError,
+				Instance: "/test",
+			},
+		},
+		{
+			"cause is not shown",
+			args{model.ErrInternalError.Wrap(errors.New("db is down")), ""},
+			problem.Details{
+				Type:     "about:blank",
+				Title:    "Internal Server Error",
+				Status:   http.StatusInternalServerError,
+				Instance: "/test",
 			},
 		},
 		{
-			"model.ErrInternalError",
-			args{model.ErrInternalError},
-			errorResponse{
-				Error: httpError{
-					Code:    model.ErrInternalError.Code(),
-					Message: model.ErrInternalError.Error(),
+			"with detail and fields",
+			args{model.ErrBadRequest.WithDetail("bad query").WithField("limit", "limit must be > 0").WithField("release", "release must be DD.MM.YYYY"), ""},
+			problem.Details{
+				Type:     "about:blank",
+				Title:    "Bad Request",
+				Status:   http.StatusBadRequest,
+				Detail:   "bad query",
+				Instance: "/test",
+				Errors: []problem.FieldError{
+					{Field: "limit", Reason: "limit must be > 0"},
+					{Field: "release", Reason: "release must be DD.MM.YYYY"},
 				},
 			},
 		},
-		// TODO: Add test cases.
+		{
+			"with request ID",
+			args{model.ErrNotFound, "req-1"},
+			problem.Details{
+				Type:      "about:blank",
+				Title:     "Not Found",
+				Status:    http.StatusNotFound,
+				Instance:  "/test",
+				RequestID: "req-1",
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			w := httptest.NewRecorder()
-			r := httptest.NewRequest("GET", "http://localhost/test", nil)
+			r := httptest.NewRequest("GET", "http://localhost/test?limit=0", nil)
+			if tt.args.requestID != "" {
+				r = r.WithContext(reqinfo.NewContext(r.Context(), &reqinfo.Info{ID: tt.args.requestID}))
+			}
 			newHelper("test", w, r).WriteError(tt.args.err)
 
 			// check status code
-			if got, want := w.Code, tt.want.Error.Code; got != want {
+			if got, want := w.Code, tt.want.Status; got != want {
 				t.Fatalf("status code = %d, want %d", got, want)
 			}
 
 			// check headers
-			for _, v := range [][2]string{{"content-type", "application/json"}} {
-				header, want := v[0], v[1]
-				got := w.Header().Get(header)
-				if got != want {
-					t.Fatalf("%s = %q, want %q", header, got, want)
-				}
+			if got, want := w.Header().Get("content-type"), problem.ContentType; got != want {
+				t.Fatalf("content-type = %q, want %q", got, want)
 			}
 
 			// check body
 			{
-				var got errorResponse
+				var got problem.Details
 				if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
 					t.Fatal(err)
 				}
 
 				if !reflect.DeepEqual(got, tt.want) {
-					t.Fatalf("got = %v (%T), want %v (%T)", got, got, tt.want, tt.want)
+					t.Fatalf("got = %+v, want %+v", got, tt.want)
 				}
 			}
 		})
 	}
 }
+
+func Test_helper_WriteError_legacy(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want problem.Legacy
+	}{
+		{
+			"model.ErrNotFound",
+			model.ErrNotFound,
+			problem.Legacy{Error: problem.LegacyError{Code: 404, Message: "404 not found"}},
+		},
+		{
+			"unknown error",
+			errors.New("unknown error"),
+			problem.Legacy{Error: problem.LegacyError{Code: 500, Message: "500 internal error"}},
+		},
+		{
+			"with fields",
+			model.ErrBadRequest.WithField("song", "song is required").WithField("group", "group is required"),
+			problem.Legacy{Error: problem.LegacyError{Code: 400, Message: "400 bad request: song is required; group is required"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "http://localhost/test", nil)
+			r.Header.Set("Accept", "application/json")
+			newHelper("test", w, r).WriteError(tt.err)
+
+			if got, want := w.Code, tt.want.Error.Code; got != want {
+				t.Fatalf("status code = %d, want %d", got, want)
+			}
+
+			if got, want := w.Header().Get("content-type"), "application/json"; got != want {
+				t.Fatalf("content-type = %q, want %q", got, want)
+			}
+
+			var got problem.Legacy
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   []string
+	}{
+		{"empty", "", nil},
+		{"single", "en", []string{"en"}},
+		{"lower case", "pt-BR", []string{"pt-br"}},
+		{"by quality", "en;q=0.5, ru, de;q=0.8", []string{"ru", "de", "en"}},
+		{"stable order", "fr, en", []string{"fr", "en"}},
+		{"wildcard", "ru, *;q=0.1", []string{"ru", "*"}},
+		{"skip q=0", "ru, en;q=0", []string{"ru"}},
+		{"skip invalid", "ru, e_n, en;q=x, de", []string{"ru", "de"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseAcceptLanguage(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseAcceptLanguage(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
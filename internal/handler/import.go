@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/problem"
+	"effective-mobile-go/internal/songio"
+)
+
+type importRowResult struct {
+	Row    int    `json:"row"`
+	Group  string `json:"group,omitempty"`
+	Song   string `json:"song,omitempty"`
+	ID     uint64 `json:"id,omitempty"`
+	Status string `json:"status" enums:"created,existing,valid,failed"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type importSongsResponse struct {
+	DryRun   bool              `json:"dryRun,omitempty"`
+	Created  int               `json:"created"`
+	Existing int               `json:"existing"`
+	Valid    int               `json:"valid,omitempty"`
+	Failed   int               `json:"failed"`
+	Rows     []importRowResult `json:"rows"`
+	// Error is set if the import is interrupted, the rows read before are processed.
+	Error *problem.Details `json:"error,omitempty"`
+}
+
+// importSongsHandler godoc
+//
+//	@Summary		Import songs from file
+//	@Description	The body is CSV (with header), JSON array or NDJSON of {group, song[, release, text, link]}.
+//	@Description	The format is taken from the format param or from the Content-Type header.
+//	@Description	The rows with missing fields are enriched through the remote API.
+//	@Description	If the body breaks after some rows are processed, they are reported with the error.
+//	@Tags			songs
+//	@Accept			plain
+//	@Produce		json
+//	@Param			format		query		string	false	"Body format"	Enums(csv, json, ndjson)
+//	@Param			dryRun		query		bool	false	"Validate only, do not write anything"
+//	@Param			concurrency	query		int		false	"Max rows processed at once"
+//	@Param			req			body		string	true	"File content"
+//	@Success		200			{object}	importSongsResponse
+//	@Failure		400			{object}	problem.Details
+//	@Failure		500			{object}	problem.Details
+//	@Router			/songs/import [post]
+func (h handler) importSongsHandler(w http.ResponseWriter, r *http.Request) {
+	x := newHelper("importSongsHandler", w, r)
+
+	q := r.URL.Query()
+
+	var (
+		format songio.Format
+		err    error
+	)
+
+	if s := q.Get("format"); s != "" {
+		format, err = songio.ParseFormat(s)
+	} else {
+		format, err = songio.FormatFromContentType(r.Header.Get("Content-Type"))
+	}
+
+	if err != nil {
+
+		x.Log().Debug("can't get format", "error", err)
+		x.WriteError(ErrBadRequest.WithField("format", "format must be csv, json or ndjson (by the param or Content-Type)"))
+		return
+	}
+
+	var opts model.ImportOptions
+
+	if s := q.Get("dryRun"); s != "" {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+
+			x.Log().Debug("can't parse dryRun", "error", err)
+			x.WriteError(ErrBadRequest.WithField("dryRun", "dryRun must be true or false"))
+			return
+		}
+		opts.DryRun = v
+	}
+
+	if s := q.Get("concurrency"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v <= 0 {
+
+			x.Log().Debug("can't parse concurrency", "error", err, "concurrency", s)
+			x.WriteError(ErrBadRequest.WithField("concurrency", "concurrency must be > 0"))
+			return
+		}
+		opts.Concurrency = v
+	}
+
+	reader, err := songio.NewReader(format, r.Body)
+	if err != nil {
+
+		x.Log().Debug("can't read body", "error", err)
+		if errors.Is(err, songio.ErrMalformed) {
+			err = ErrBadRequest.WithDetail("can't read body: " + err.Error()).Wrap(err)
+		}
+		x.WriteError(err)
+		return
+	}
+
+	x.Log().Debug("http request parsed", "format", format, "opts", opts)
+
+	results, err := h.ImportSongs(x.Ctx(), reader, opts)
+	if err != nil && len(results) == 0 {
+		x.WriteError(err)
+		return
+	}
+
+	resp := importSongsResponse{
+		DryRun: opts.DryRun,
+		Rows:   []importRowResult{}, // guarantee not nil
+	}
+
+	if err != nil {
+
+		// the processed rows are written already, the client must know them
+		x.Log().Debug("import interrupted", "error", err, "rows", len(results))
+		details := problem.New(x.r, x.HTTPError(err))
+		resp.Error = &details
+	}
+
+	for _, v := range results {
+
+		switch v.Status {
+		case model.ImportCreated:
+			resp.Created++
+		case model.ImportExisting:
+			resp.Existing++
+		case model.ImportValid:
+			resp.Valid++
+		case model.ImportFailed:
+			resp.Failed++
+		}
+
+		resp.Rows = append(resp.Rows, importRowResult{
+			Row:    v.Row,
+			Group:  v.Group,
+			Song:   v.Song,
+			ID:     v.ID,
+			Status: string(v.Status),
+			Reason: v.Reason,
+		})
+	}
+
+	x.WriteResponse(&resp)
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"effective-mobile-go/internal/auth"
+	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/songio"
+)
+
+// importService creates every row read, like the service it returns the rows processed
+// before the input breaks with the error.
+type importService struct {
+	Service
+}
+
+func (importService) ImportSongs(_ context.Context, r songio.Reader, _ model.ImportOptions) ([]model.ImportResult, error) {
+
+	var results []model.ImportResult
+
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return results, nil
+		}
+		if errors.Is(err, songio.ErrMalformed) {
+			return results, ErrBadRequest.WithDetail(err.Error()).Wrap(err)
+		}
+		if err != nil {
+			return results, err
+		}
+
+		results = append(results, model.ImportResult{
+			Row: row.Row, Group: row.Group, Song: row.Song, ID: uint64(row.Row), Status: model.ImportCreated,
+		})
+	}
+}
+
+func Test_importSongsHandler_interrupted(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		body        string
+		wantCode    int
+		wantRows    int
+		interrupted bool
+	}{
+		{"complete", `[{"group":"Muse","song":"Uprising"},{"group":"Muse","song":"Hysteria"}]`, http.StatusOK, 2, false},
+		{"malformed after rows", `[{"group":"Muse","song":"Uprising"},{"group":"Muse","song":"Hysteria"},}`, http.StatusOK, 2, true},
+		{"malformed at once", `[}`, http.StatusBadRequest, 0, true},
+	}
+
+	h := New(importService{}, nil)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "http://localhost/songs/import?format=json", strings.NewReader(tt.body))
+			r = r.WithContext(auth.ContextWithPrincipal(r.Context(), model.Principal{Method: model.AuthAPIKey, Roles: []string{"admin"}}))
+
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status code = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var resp importSongsResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Created != tt.wantRows || len(resp.Rows) != tt.wantRows {
+				t.Fatalf("created = %d, rows = %+v, want %d", resp.Created, resp.Rows, tt.wantRows)
+			}
+
+			if (resp.Error != nil) != tt.interrupted {
+				t.Fatalf("error = %+v, want interrupted %t", resp.Error, tt.interrupted)
+			}
+
+			if tt.interrupted && resp.Error.Status != http.StatusBadRequest {
+				t.Errorf("error status = %d, want 400", resp.Error.Status)
+			}
+		})
+	}
+}
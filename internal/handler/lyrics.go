@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"net/http"
+
+	"effective-mobile-go/internal/model"
+)
+
+type songLyrics struct {
+	Lang       string `json:"lang"`
+	Original   bool   `json:"original"`
+	Translator string `json:"translator,omitempty"`
+}
+
+type listSongLyricsResponse struct {
+	Lyrics []songLyrics `json:"lyrics"`
+}
+
+// listSongLyricsHandler godoc
+//
+//	@Summary	List languages of song lyrics
+//	@Tags		lyrics
+//	@Produce	json
+//	@Param		id	path		uint	true	"Song id"
+//	@Success	200	{object}	listSongLyricsResponse
+//	@Failure	400	{object}	problem.Details
+//	@Failure	404	{object}	problem.Details
+//	@Failure	500	{object}	problem.Details
+//	@Router		/songs/{id}/lyrics [get]
+func (h handler) listSongLyricsHandler(w http.ResponseWriter, r *http.Request) {
+	x := newHelper("listSongLyricsHandler", w, r)
+
+	songID, err := x.GetID()
+	if err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	x.Log().Debug("http request parsed", "songID", songID)
+
+	list, err := h.ListSongLyrics(x.Ctx(), songID)
+	if err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	resp := listSongLyricsResponse{Lyrics: []songLyrics{}} // guarantee not nil
+
+	for i := range list {
+		lyrics := &list[i]
+		resp.Lyrics = append(resp.Lyrics, songLyrics{
+			Lang:       lyrics.Lang,
+			Original:   lyrics.Original,
+			Translator: lyrics.Translator,
+		})
+	}
+
+	x.WriteResponse(&resp)
+}
+
+type putSongLyricsRequest struct {
+	Text       string `json:"text" example:"Ooh baby, don't you know I suffer?"`
+	Original   bool   `json:"original"`
+	Translator string `json:"translator,omitempty"`
+}
+
+type putSongLyricsResponse struct {
+	Lyrics songLyrics `json:"lyrics"`
+}
+
+// putSongLyricsHandler godoc
+//
+//	@Summary		Create or replace song lyrics in the language
+//	@Description	The original lyrics also replace the song text.
+//	@Tags			lyrics
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		uint					true	"Song id"
+//	@Param			lang	path		string					true	"Language code (example: en, pt-BR)"
+//	@Param			req		body		putSongLyricsRequest	true	"PutSongLyricsRequest"
+//	@Success		200		{object}	putSongLyricsResponse
+//	@Failure		400		{object}	problem.Details
+//	@Failure		404		{object}	problem.Details
+//	@Failure		500		{object}	problem.Details
+//	@Router			/songs/{id}/lyrics/{lang} [put]
+func (h handler) putSongLyricsHandler(w http.ResponseWriter, r *http.Request) {
+	x := newHelper("putSongLyricsHandler", w, r)
+
+	songID, err := x.GetID()
+	if err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	lang, err := x.GetLang()
+	if err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	var req putSongLyricsRequest
+
+	if err := x.DecodeBody(&req); err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	if req.Text == "" {
+
+		x.Log().Debug("text is required")
+		x.WriteError(ErrBadRequest.WithField("text", "text is required"))
+		return
+	}
+
+	lyrics := model.SongLyrics{
+		SongID:     songID,
+		Lang:       lang,
+		Original:   req.Original,
+		Translator: req.Translator,
+		Text:       req.Text,
+	}
+
+	x.Log().Debug("http request parsed", "songID", songID, "lang", lang)
+
+	lyrics, err = h.PutSongLyrics(x.Ctx(), lyrics)
+	if err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	resp := putSongLyricsResponse{
+		Lyrics: songLyrics{
+			Lang:       lyrics.Lang,
+			Original:   lyrics.Original,
+			Translator: lyrics.Translator,
+		},
+	}
+
+	x.WriteResponse(&resp)
+}
+
+// deleteSongLyricsHandler godoc
+//
+//	@Summary	Delete song lyrics in the language
+//	@Tags		lyrics
+//	@Produce	json
+//	@Param		id		path		uint	true	"Song id"
+//	@Param		lang	path		string	true	"Language code (example: en, pt-BR)"
+//	@Success	200		{object}	emptyResponse
+//	@Failure	400		{object}	problem.Details
+//	@Failure	404		{object}	problem.Details
+//	@Failure	500		{object}	problem.Details
+//	@Router		/songs/{id}/lyrics/{lang} [delete]
+func (h handler) deleteSongLyricsHandler(w http.ResponseWriter, r *http.Request) {
+	x := newHelper("deleteSongLyricsHandler", w, r)
+
+	songID, err := x.GetID()
+	if err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	lang, err := x.GetLang()
+	if err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	x.Log().Debug("http request parsed", "songID", songID, "lang", lang)
+
+	if err := h.DeleteSongLyrics(x.Ctx(), songID, lang); err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	x.WriteResponse(&emptyResponse{})
+}
@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"effective-mobile-go/docs"
+	"effective-mobile-go/internal/auth"
+	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/openapi"
+	"effective-mobile-go/internal/problem"
+	"effective-mobile-go/internal/songio"
+)
+
+// sampleService answers every call with a sample, so every field of the responses is
+// checked against the spec.
+type sampleService struct{}
+
+var sampleSong = model.SongDetail{
+	ID:      1,
+	Name:    "Uprising",
+	Group:   "Muse",
+	Release: model.Date{Time: time.Date(2009, 9, 7, 0, 0, 0, 0, time.UTC)},
+	Text:    "Paranoia is in bloom\n\nThe PR transmissions will resume",
+	Link:    "https://example.com/uprising",
+}
+
+func (sampleService) CreateSong(context.Context, model.SongDetail) (model.SongDetail, error) {
+	return sampleSong, nil
+}
+
+func (sampleService) ListSongs(context.Context, model.SongFilters) ([]model.SongDetail, error) {
+	return []model.SongDetail{sampleSong}, nil
+}
+
+func (sampleService) GetSong(context.Context, uint64) (model.SongDetail, error) {
+	return sampleSong, nil
+}
+
+func (sampleService) GetSongText(_ context.Context, req model.GetSongTextRequest) (model.SongText, error) {
+	text := model.SongText{Lang: "en", Original: true, Verses: []string{"Paranoia is in bloom"}}
+	if req.SideBySide {
+		text = model.SongText{Lang: "ru", Translator: "someone", Verses: []string{"Паранойя в цвету"}, OriginalVerses: text.Verses}
+	}
+	return text, nil
+}
+
+func (sampleService) UpdateSong(context.Context, model.SongUpdate) (model.SongDetail, error) {
+	return sampleSong, nil
+}
+
+func (sampleService) DeleteSong(context.Context, uint64) error {
+	return nil
+}
+
+func (sampleService) ListSongLyrics(context.Context, uint64) ([]model.SongLyrics, error) {
+	return []model.SongLyrics{{SongID: 1, Lang: "en", Original: true, Text: sampleSong.Text}}, nil
+}
+
+func (sampleService) PutSongLyrics(_ context.Context, lyrics model.SongLyrics) (model.SongLyrics, error) {
+	return lyrics, nil
+}
+
+func (sampleService) DeleteSongLyrics(context.Context, uint64, string) error {
+	return nil
+}
+
+func (sampleService) GetSongStats(context.Context, uint64) (model.SongStats, error) {
+	return model.SongStats{
+		SongID: 1, Verses: 2, Lines: 2, Words: 9, UniqueWords: 9, UniqueRatio: 1, Lang: "en",
+		ReadingTime: 3 * time.Second, WordFreq: []model.WordCount{{Word: "paranoia", Count: 1}},
+	}, nil
+}
+
+func (sampleService) ImportSongs(context.Context, songio.Reader, model.ImportOptions) ([]model.ImportResult, error) {
+	return []model.ImportResult{{Row: 1, Group: "Muse", Song: "Uprising", ID: 1, Status: model.ImportCreated}}, nil
+}
+
+func (sampleService) ExportSongs(_ context.Context, _ model.SongFilters, _ bool, fn func(model.SongDetail) error) error {
+	return fn(sampleSong)
+}
+
+func (sampleService) BatchCreateSongs(context.Context, []model.SongDetail, bool) ([]model.BatchResult, bool, error) {
+	return []model.BatchResult{{Index: 0, ID: 1, Status: model.BatchOK, Song: &sampleSong}}, true, nil
+}
+
+func (sampleService) BatchUpdateSongs(context.Context, []model.SongUpdate, bool) ([]model.BatchResult, bool, error) {
+	return []model.BatchResult{{Index: 0, ID: 1, Status: model.BatchFailed, Reason: "404 not found"}}, true, nil
+}
+
+func (sampleService) BatchDeleteSongs(context.Context, []uint64, bool) ([]model.BatchResult, bool, error) {
+	return []model.BatchResult{{Index: 0, ID: 1, Status: model.BatchOK}}, true, nil
+}
+
+func (sampleService) CreateAPIKey(_ context.Context, name string, roles []string) (model.APIKey, string, error) {
+	return model.APIKey{ID: 1, Name: name, Prefix: "sk_abcd", Roles: roles, CreatedAt: time.Now()}, "sk_abcdef", nil
+}
+
+func (sampleService) ListAPIKeys(context.Context) ([]model.APIKey, error) {
+	return []model.APIKey{{ID: 1, Name: "ops", Prefix: "sk_abcd", Roles: []string{"admin"}, CreatedAt: time.Now()}}, nil
+}
+
+func (sampleService) RevokeAPIKey(context.Context, uint64) error {
+	return nil
+}
+
+func newSpecValidator(t *testing.T) *openapi.Validator {
+	t.Helper()
+
+	v, err := openapi.New([]byte(docs.SwaggerInfo.ReadDoc()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// TestSpec_routes fails if a route is added or removed without the swag annotations
+// (run `make docs`).
+func TestSpec_routes(t *testing.T) {
+
+	var routes []string
+	for _, route := range (handler{}).routes() {
+		routes = append(routes, strings.Join(strings.Fields(route.pattern), " "))
+	}
+	routes = append(routes, "GET /ping")
+	sort.Strings(routes)
+
+	if got, want := newSpecValidator(t).Operations(), routes; !reflect.DeepEqual(got, want) {
+		t.Fatalf("spec operations = %v,\nroutes = %v", got, want)
+	}
+}
+
+// TestSpec_responses fails if a handler responds what its swag annotations don't declare.
+func TestSpec_responses(t *testing.T) {
+
+	v := newSpecValidator(t)
+
+	tests := []struct {
+		method, url, body string
+		want              int
+	}{
+		{"GET", "/ping", "", 200},
+		{"GET", "/songs?group=Muse&release=07.09.2009&limit=10", "", 200},
+		{"POST", "/songs", `{"song":"Uprising","group":"Muse"}`, 200},
+		{"POST", "/songs/import?format=csv&dryRun=true", "group,song\nMuse,Uprising\n", 200},
+		{"GET", "/songs/export?format=ndjson&withText=true", "", 200},
+		{"POST", "/songs:batchCreate", `{"songs":[{"song":"Uprising","group":"Muse"}]}`, 200},
+		{"PATCH", "/songs:batchUpdate", `{"mode":"bestEffort","songs":[{"id":1,"link":"https://example.com"}]}`, 200},
+		{"POST", "/songs:batchDelete", `{"ids":[1]}`, 200},
+		{"GET", "/songs/1", "", 200},
+		{"GET", "/songs/1/text", "", 200},
+		{"GET", "/songs/1/text?lang=ru&sideBySide=true", "", 200},
+		{"GET", "/songs/1/stats", "", 200},
+		{"POST", "/songs/1", `{"release":"07.09.2009","link":"https://example.com"}`, 200},
+		{"DELETE", "/songs/1", "", 200},
+		{"GET", "/songs/1/lyrics", "", 200},
+		{"PUT", "/songs/1/lyrics/ru", `{"text":"Паранойя в цвету","translator":"someone"}`, 200},
+		{"DELETE", "/songs/1/lyrics/ru", "", 200},
+		{"GET", "/apikeys", "", 200},
+		{"POST", "/apikeys", `{"name":"ops","roles":["editor"]}`, 200},
+		{"DELETE", "/apikeys/1", "", 200},
+
+		// rejected by the spec
+		{"GET", "/songs?limit=x", "", 400},
+		{"GET", "/songs/x", "", 400},
+		{"POST", "/songs", `{"song":"Uprising"}`, 400},
+
+		// rejected by the handler
+		{"GET", "/songs?limit=0", "", 400},
+		{"POST", "/songs/1", `{"link":"example.com"}`, 400},
+	}
+
+	admin := model.Principal{Method: model.AuthAPIKey, Roles: []string{model.RoleAdmin}}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.url, func(t *testing.T) {
+
+			v.OnResponseError = func(r *http.Request, err error) {
+				t.Errorf("response doesn't follow the spec: %v", err)
+			}
+
+			h := http.StripPrefix("/api/v1", New(sampleService{}, nil))
+			h = v.Handler(h, true)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "http://localhost/api/v1"+tt.url, strings.NewReader(tt.body))
+			r = r.WithContext(auth.ContextWithPrincipal(r.Context(), admin))
+			if tt.body != "" && !strings.Contains(tt.url, "import") {
+				r.Header.Set("Content-Type", "application/json")
+			}
+
+			h.ServeHTTP(w, r)
+
+			if got, want := w.Code, tt.want; got != want {
+				t.Fatalf("status code = %d, want %d: %s", got, want, w.Body)
+			}
+
+			if w.Code == http.StatusBadRequest {
+				var resp problem.Details
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatal(err)
+				}
+				if len(resp.Errors) == 0 {
+					t.Fatalf("no invalid fields in %+v", resp)
+				}
+			}
+		})
+	}
+}
+
+type countingReader struct {
+	io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += n
+	return n, err
+}
+
+// TestSpec_body checks that the file bodies are streamed to the handler, not validated,
+// and that the validated ones are limited.
+func TestSpec_body(t *testing.T) {
+
+	v := newSpecValidator(t)
+
+	body := &countingReader{Reader: strings.NewReader("group,song\n" + strings.Repeat("Muse,Uprising\n", 1<<20))}
+	read := -1
+
+	h := v.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		read = body.n
+		io.Copy(io.Discard, r.Body)
+	}), false)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "http://localhost/api/v1/songs/import?format=csv", body))
+
+	if w.Code != http.StatusOK || read != 0 {
+		t.Fatalf("import: status code = %d, read before the handler = %d bytes, want 200 and 0", w.Code, read)
+	}
+
+	large := `{"song":"Uprising","group":"Muse","text":"` + strings.Repeat("x", 10<<20) + `"}`
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "http://localhost/api/v1/songs", strings.NewReader(large))
+	r.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("large body: status code = %d, want 413: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/ratelimit"
+)
+
+type route struct {
+	pattern string
+	role    string // the least role allowed to call
+	class   ratelimit.Class
+	handler http.HandlerFunc
+}
+
+// routes is the access policy: readers read, editors create and update, admins delete,
+// import and manage API keys. The routes calling the remote API are rate limited apart
+// from the other writes.
+func (h handler) routes() []route {
+	const (
+		reader = model.RoleReader
+		editor = model.RoleEditor
+		admin  = model.RoleAdmin
+
+		read   = ratelimit.Read
+		write  = ratelimit.Write
+		remote = ratelimit.Remote
+	)
+
+	return []route{
+		{"GET    /songs", reader, read, h.listSongsHandler},
+		{"POST   /songs", editor, remote, h.createSongHandler},
+		{"POST   /songs/import", admin, remote, h.importSongsHandler},
+		{"GET    /songs/export", reader, read, h.exportSongsHandler},
+
+		{"POST   /songs:batchCreate", editor, remote, h.batchCreateSongsHandler},
+		{"PATCH  /songs:batchUpdate", editor, write, h.batchUpdateSongsHandler},
+		{"POST   /songs:batchDelete", admin, write, h.batchDeleteSongsHandler},
+
+		{"GET    /songs/{id}", reader, read, h.getSongHandler},
+		{"GET    /songs/{id}/text", reader, read, h.getSongTextHandler},
+		{"GET    /songs/{id}/stats", reader, read, h.getSongStatsHandler},
+		{"POST   /songs/{id}", editor, write, h.updateSongHandler},
+		{"DELETE /songs/{id}", admin, write, h.deleteSongHandler},
+
+		{"GET    /songs/{id}/lyrics", reader, read, h.listSongLyricsHandler},
+		{"PUT    /songs/{id}/lyrics/{lang}", editor, write, h.putSongLyricsHandler},
+		{"DELETE /songs/{id}/lyrics/{lang}", admin, write, h.deleteSongLyricsHandler},
+
+		{"GET    /apikeys", admin, read, h.listAPIKeysHandler},
+		{"POST   /apikeys", admin, write, h.createAPIKeyHandler},
+		{"DELETE /apikeys/{id}", admin, write, h.revokeAPIKeyHandler},
+	}
+}
+
+// authorize calls the handler if the caller has the role, otherwise responds 401 to
+// anonymous callers and 403 to the rest.
+func authorize(role string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		x := newHelper("authorize", w, r)
+
+		if err := x.RequireRole(role); err != nil {
+			if errors.Is(err, ErrUnauthorized) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			}
+			x.WriteError(err)
+			return
+		}
+
+		h(w, r)
+	}
+}
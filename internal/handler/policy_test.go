@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"effective-mobile-go/internal/auth"
+	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/problem"
+)
+
+// notFoundService answers ErrNotFound to everything the policy test calls, so 404 means
+// the request has passed the authorization.
+type notFoundService struct {
+	Service
+}
+
+func (notFoundService) GetSong(context.Context, uint64) (model.SongDetail, error) {
+	return model.SongDetail{}, ErrNotFound
+}
+
+func (notFoundService) ListSongs(context.Context, model.SongFilters) ([]model.SongDetail, error) {
+	return nil, ErrNotFound
+}
+
+func (notFoundService) CreateSong(context.Context, model.SongDetail) (model.SongDetail, error) {
+	return model.SongDetail{}, ErrNotFound
+}
+
+func (notFoundService) UpdateSong(context.Context, model.SongUpdate) (model.SongDetail, error) {
+	return model.SongDetail{}, ErrNotFound
+}
+
+func (notFoundService) DeleteSong(context.Context, uint64) error {
+	return ErrNotFound
+}
+
+func (notFoundService) ListSongLyrics(context.Context, uint64) ([]model.SongLyrics, error) {
+	return nil, ErrNotFound
+}
+
+func (notFoundService) BatchDeleteSongs(context.Context, []uint64, bool) ([]model.BatchResult, bool, error) {
+	return nil, false, ErrNotFound
+}
+
+func (notFoundService) ListAPIKeys(context.Context) ([]model.APIKey, error) {
+	return nil, ErrNotFound
+}
+
+func TestNew_policy(t *testing.T) {
+
+	requests := []struct {
+		method, url, body string
+	}{
+		{"GET", "/songs", ""},
+		{"GET", "/songs/1", ""},
+		{"GET", "/songs/1/lyrics", ""},
+		{"POST", "/songs", `{"song":"Uprising","group":"Muse"}`},
+		{"POST", "/songs/1", `{"link":"https://example.com"}`},
+		{"DELETE", "/songs/1", ""},
+		{"POST", "/songs:batchDelete", `{"ids":[1]}`},
+		{"GET", "/apikeys", ""},
+	}
+
+	const (
+		allowed      = http.StatusNotFound
+		unauthorized = http.StatusUnauthorized
+		forbidden    = http.StatusForbidden
+	)
+
+	tests := []struct {
+		name      string
+		principal *model.Principal
+		want      []int // by requests
+	}{
+		{
+			"no principal",
+			nil,
+			[]int{unauthorized, unauthorized, unauthorized, unauthorized, unauthorized, unauthorized, unauthorized, unauthorized},
+		},
+		{
+			"anonymous without role",
+			&model.Principal{Method: model.AuthAnonymous},
+			[]int{unauthorized, unauthorized, unauthorized, unauthorized, unauthorized, unauthorized, unauthorized, unauthorized},
+		},
+		{
+			"anonymous reader",
+			&model.Principal{Method: model.AuthAnonymous, Roles: []string{"reader"}},
+			[]int{allowed, allowed, allowed, unauthorized, unauthorized, unauthorized, unauthorized, unauthorized},
+		},
+		{
+			"unknown role",
+			&model.Principal{Method: model.AuthJWT, Roles: []string{"guest"}},
+			[]int{forbidden, forbidden, forbidden, forbidden, forbidden, forbidden, forbidden, forbidden},
+		},
+		{
+			"reader",
+			&model.Principal{Method: model.AuthAPIKey, Roles: []string{"reader"}},
+			[]int{allowed, allowed, allowed, forbidden, forbidden, forbidden, forbidden, forbidden},
+		},
+		{
+			"editor",
+			&model.Principal{Method: model.AuthAPIKey, Roles: []string{"editor"}},
+			[]int{allowed, allowed, allowed, allowed, allowed, forbidden, forbidden, forbidden},
+		},
+		{
+			"admin",
+			&model.Principal{Method: model.AuthJWT, Roles: []string{"admin"}},
+			[]int{allowed, allowed, allowed, allowed, allowed, allowed, allowed, allowed},
+		},
+		{
+			"reader and editor",
+			&model.Principal{Method: model.AuthJWT, Roles: []string{"reader", "editor"}},
+			[]int{allowed, allowed, allowed, allowed, allowed, forbidden, forbidden, forbidden},
+		},
+	}
+
+	h := New(notFoundService{}, nil)
+
+	for _, tt := range tests {
+		for i, req := range requests {
+			t.Run(tt.name+" "+req.method+" "+req.url, func(t *testing.T) {
+
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(req.method, "http://localhost"+req.url, strings.NewReader(req.body))
+				if tt.principal != nil {
+					r = r.WithContext(auth.ContextWithPrincipal(r.Context(), *tt.principal))
+				}
+
+				h.ServeHTTP(w, r)
+
+				if got, want := w.Code, tt.want[i]; got != want {
+					t.Fatalf("status code = %d, want %d", got, want)
+				}
+
+				var resp problem.Details
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatal(err)
+				}
+
+				if got, want := resp.Status, tt.want[i]; got != want {
+					t.Fatalf("error code = %d, want %d", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestNew_ping_is_public(t *testing.T) {
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "http://localhost/ping", nil)
+
+	New(notFoundService{}, nil).ServeHTTP(w, r)
+
+	if got, want := w.Code, http.StatusOK; got != want {
+		t.Fatalf("status code = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+)
+
+type songStats struct {
+	Verses      int         `json:"verses"`
+	Lines       int         `json:"lines"`
+	Words       int         `json:"words"`
+	UniqueWords int         `json:"uniqueWords"`
+	UniqueRatio float64     `json:"uniqueRatio"`
+	Lang        string      `json:"lang,omitempty"`
+	ReadingTime int64       `json:"readingTime"` // seconds
+	WordFreq    []wordCount `json:"wordFreq"`
+}
+
+type wordCount struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+type getSongStatsResponse struct {
+	Stats songStats `json:"stats"`
+}
+
+// getSongStatsHandler godoc
+//
+//	@Summary		Get song text statistics
+//	@Description	Verse, line and word counts, most frequent words, detected language
+//	@Description	and estimated reading time (seconds).
+//	@Tags			songs
+//	@Produce		json
+//	@Param			id	path		uint	true	"Song id"
+//	@Success		200	{object}	getSongStatsResponse
+//	@Failure		400	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/songs/{id}/stats [get]
+func (h handler) getSongStatsHandler(w http.ResponseWriter, r *http.Request) {
+	x := newHelper("getSongStatsHandler", w, r)
+
+	songID, err := x.GetID()
+	if err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	x.Log().Debug("http request parsed", "songID", songID)
+
+	stats, err := h.GetSongStats(x.Ctx(), songID)
+	if err != nil {
+		x.WriteError(err)
+		return
+	}
+
+	resp := getSongStatsResponse{
+		Stats: songStats{
+			Verses:      stats.Verses,
+			Lines:       stats.Lines,
+			Words:       stats.Words,
+			UniqueWords: stats.UniqueWords,
+			UniqueRatio: stats.UniqueRatio,
+			Lang:        stats.Lang,
+			ReadingTime: int64(stats.ReadingTime / time.Second),
+			WordFreq:    []wordCount{}, // guarantee not nil
+		},
+	}
+
+	for _, v := range stats.WordFreq {
+		resp.Stats.WordFreq = append(resp.Stats.WordFreq, wordCount{Word: v.Word, Count: v.Count})
+	}
+
+	x.WriteResponse(&resp)
+}
@@ -0,0 +1,181 @@
+// Package health serves the liveness and readiness probes. The readiness runs the
+// dependency checks concurrently and reports every check with its latency.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"effective-mobile-go/internal/logger"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+var ErrShuttingDown = errors.New("shutting down")
+
+// Check returns nil if the dependency is ready.
+type Check func(context.Context) error
+
+type Checker struct {
+	timeout      atomic.Int64 // time.Duration
+	checks       []*check
+	shuttingDown atomic.Bool
+}
+
+type check struct {
+	name string
+	fn   Check
+	ttl  time.Duration // the result is cached if not zero
+
+	mu     sync.Mutex
+	result Result
+	at     time.Time
+}
+
+// Result is the result of one check.
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+	Cached    bool    `json:"cached,omitempty"`
+}
+
+// Report is the response of the probes.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// New returns the checker. Every check is given the timeout.
+func New(timeout time.Duration) *Checker {
+	c := &Checker{}
+	c.SetTimeout(timeout)
+	return c
+}
+
+// SetTimeout changes the timeout of the checks started after the call.
+func (c *Checker) SetTimeout(timeout time.Duration) {
+	c.timeout.Store(int64(timeout))
+}
+
+// Add adds the readiness check. If ttl is not zero, the result is reused for that time,
+// which is for the checks that are expensive or count against a quota.
+func (c *Checker) Add(name string, fn Check, ttl time.Duration) {
+	c.checks = append(c.checks, &check{name: name, fn: fn, ttl: ttl})
+}
+
+// ShutDown makes the readiness fail, so the load balancer stops sending requests
+// before the server stops accepting them.
+func (c *Checker) ShutDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Ready runs the checks and returns the report.
+func (c *Checker) Ready(ctx context.Context) Report {
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(c.checks)+1)}
+
+	if c.shuttingDown.Load() {
+		report.Status = StatusFail
+		report.Checks["shutdown"] = Result{Status: StatusFail, Error: ErrShuttingDown.Error()}
+		return report
+	}
+
+	results := make([]Result, len(c.checks))
+	timeout := time.Duration(c.timeout.Load())
+
+	var wg sync.WaitGroup
+	for i, ch := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = ch.run(ctx, timeout)
+		}()
+	}
+	wg.Wait()
+
+	for i, ch := range c.checks {
+		report.Checks[ch.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+
+	return report
+}
+
+func (ch *check) run(ctx context.Context, timeout time.Duration) Result {
+
+	if ch.ttl > 0 {
+		ch.mu.Lock() // the concurrent probes wait for one run
+		defer ch.mu.Unlock()
+
+		if !ch.at.IsZero() && time.Since(ch.at) < ch.ttl {
+			res := ch.result
+			res.Cached = true
+			return res
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := ch.fn(ctx)
+
+	res := Result{Status: StatusOK, LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+
+	if ch.ttl > 0 {
+		ch.result, ch.at = res, time.Now()
+	}
+	return res
+}
+
+// Liveness responds 200 while the process can serve requests at all.
+func (c *Checker) Liveness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, r, Report{Status: StatusOK})
+	})
+}
+
+// Readiness responds 200 if all checks pass, 503 otherwise.
+func (c *Checker) Readiness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		report := c.Ready(r.Context())
+
+		if report.Status != StatusOK {
+			logger.GetLoggerFromContextOrDefault(r.Context()).Warn("not ready", "checks", report.Checks)
+		}
+
+		writeReport(w, r, report)
+	})
+}
+
+func writeReport(w http.ResponseWriter, r *http.Request, report Report) {
+
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.Header().Set("cache-control", "no-store")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(&report); err != nil {
+		logger.GetLoggerFromContextOrDefault(r.Context()).Error("can't write response", "error", err)
+	}
+}
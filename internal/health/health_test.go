@@ -0,0 +1,127 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChecker_Readiness(t *testing.T) {
+
+	ok := func(context.Context) error { return nil }
+	fail := func(context.Context) error { return errors.New("down") }
+	slow := func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }
+
+	tests := []struct {
+		name       string
+		checks     map[string]Check
+		shutDown   bool
+		wantCode   int
+		wantStatus map[string]string
+	}{
+		{"no checks", nil, false, http.StatusOK, map[string]string{}},
+		{"all ok", map[string]Check{"db": ok, "remote": ok}, false, http.StatusOK,
+			map[string]string{"db": StatusOK, "remote": StatusOK}},
+		{"one fails", map[string]Check{"db": ok, "remote": fail}, false, http.StatusServiceUnavailable,
+			map[string]string{"db": StatusOK, "remote": StatusFail}},
+		{"timeout", map[string]Check{"db": slow}, false, http.StatusServiceUnavailable,
+			map[string]string{"db": StatusFail}},
+		{"shutting down", map[string]Check{"db": ok}, true, http.StatusServiceUnavailable,
+			map[string]string{"shutdown": StatusFail}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			c := New(10 * time.Millisecond)
+			for name, fn := range tt.checks {
+				c.Add(name, fn, 0)
+			}
+			if tt.shutDown {
+				c.ShutDown()
+			}
+
+			w := httptest.NewRecorder()
+			c.Readiness().ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+
+			if got, want := w.Code, tt.wantCode; got != want {
+				t.Fatalf("status code = %d, want %d", got, want)
+			}
+
+			var report Report
+			if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(report.Checks) != len(tt.wantStatus) {
+				t.Fatalf("checks = %+v, want %v", report.Checks, tt.wantStatus)
+			}
+			for name, want := range tt.wantStatus {
+				if got := report.Checks[name].Status; got != want {
+					t.Fatalf("%s status = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestChecker_cached(t *testing.T) {
+
+	var calls atomic.Int32
+
+	c := New(time.Second)
+	c.Add("remote", func(context.Context) error { calls.Add(1); return nil }, time.Hour)
+
+	first := c.Ready(context.Background())
+	second := c.Ready(context.Background())
+
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("calls = %d, want 1", got)
+	}
+
+	if first.Checks["remote"].Cached || !second.Checks["remote"].Cached {
+		t.Fatalf("cached = %v, %v, want false, true", first.Checks["remote"].Cached, second.Checks["remote"].Cached)
+	}
+}
+
+// TestChecker_concurrent runs the concurrent probes, it is for the race detector.
+func TestChecker_concurrent(t *testing.T) {
+
+	check := func(context.Context) error { time.Sleep(10 * time.Millisecond); return nil }
+
+	c := New(time.Second)
+	c.Add("db", check, 0)
+	c.Add("remote", check, time.Hour)
+
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if report := c.Ready(context.Background()); report.Status != StatusOK {
+				t.Errorf("report = %+v, want ok", report)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestChecker_Liveness(t *testing.T) {
+
+	c := New(time.Second)
+	c.Add("db", func(context.Context) error { return errors.New("down") }, 0)
+	c.ShutDown()
+
+	w := httptest.NewRecorder()
+	c.Liveness().ServeHTTP(w, httptest.NewRequest("GET", "/healthz", nil))
+
+	if got, want := w.Code, http.StatusOK; got != want {
+		t.Fatalf("status code = %d, want %d", got, want)
+	}
+}
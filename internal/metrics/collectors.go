@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+
+	"effective-mobile-go/internal/cache"
+)
+
+// RegisterDB exports the connection pool stats of sql.DB.
+func RegisterDB(db *sql.DB) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+}
+
+// RegisterCache exports the hit, miss and eviction counters and the size of the cache.
+func RegisterCache(name string, stats func() cache.Stats) {
+	prometheus.MustRegister(&cacheCollector{
+		stats: stats,
+		hits: prometheus.NewDesc(prometheus.BuildFQName(namespace, "cache", "hits_total"),
+			"Cache hits.", nil, prometheus.Labels{"cache": name}),
+		misses: prometheus.NewDesc(prometheus.BuildFQName(namespace, "cache", "misses_total"),
+			"Cache misses.", nil, prometheus.Labels{"cache": name}),
+		evictions: prometheus.NewDesc(prometheus.BuildFQName(namespace, "cache", "evictions_total"),
+			"Entries evicted from the full cache.", nil, prometheus.Labels{"cache": name}),
+		entries: prometheus.NewDesc(prometheus.BuildFQName(namespace, "cache", "entries"),
+			"Entries in the cache.", nil, prometheus.Labels{"cache": name}),
+	})
+}
+
+type cacheCollector struct {
+	stats                            func() cache.Stats
+	hits, misses, evictions, entries *prometheus.Desc
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+	ch <- c.entries
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(s.Misses))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(s.Evictions))
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(s.Len))
+}
@@ -0,0 +1,123 @@
+// Package metrics defines the Prometheus metrics of the service.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"effective-mobile-go/internal/reqinfo"
+)
+
+const namespace = "songlib"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route pattern and status code.",
+	}, []string{"route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route pattern and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "status"})
+
+	panics = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_panics_total",
+		Help:      "Panics recovered in HTTP handlers.",
+	})
+
+	remoteDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "remote_request_duration_seconds",
+		Help:      "Music info API request latency by outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"outcome"})
+
+	remoteErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "remote_errors_total",
+		Help:      "Failed music info API requests by outcome.",
+	}, []string{"outcome"})
+
+	songsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "songs_created_total",
+		Help:      "Songs added to the library.",
+	})
+
+	enrichmentFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "enrichment_failures_total",
+		Help:      "Failed remote lookups of song details by reason (not_found or error).",
+	}, []string{"reason"})
+)
+
+// Remote request outcomes.
+const (
+	RemoteOK          = "ok"
+	RemoteNotFound    = "not_found"
+	RemoteBadRequest  = "bad_request"
+	RemoteBadStatus   = "bad_status"
+	RemoteTransport   = "transport_error"
+	RemoteBadResponse = "bad_response"
+)
+
+func ObserveRemoteRequest(outcome string, d time.Duration) {
+	remoteDuration.WithLabelValues(outcome).Observe(d.Seconds())
+	if outcome != RemoteOK && outcome != RemoteNotFound {
+		remoteErrors.WithLabelValues(outcome).Inc()
+	}
+}
+
+func PanicRecovered() {
+	panics.Inc()
+}
+
+func SongCreated() {
+	songsCreated.Inc()
+}
+
+func EnrichmentFailed(notFound bool) {
+	reason := "error"
+	if notFound {
+		reason = "not_found"
+	}
+	enrichmentFailures.WithLabelValues(reason).Inc()
+}
+
+// unmatchedRoute is the label of requests not matched by any route pattern, so that
+// unknown URLs do not blow up the label cardinality.
+const unmatchedRoute = "unmatched"
+
+// Route returns the handler which reports the route pattern of the request.
+func Route(pattern string, h http.Handler) http.Handler {
+
+	pattern = strings.Join(strings.Fields(pattern), " ") // "GET    /songs" -> "GET /songs"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqinfo.FromContext(r.Context()).Route = pattern
+		h.ServeHTTP(w, r)
+	})
+}
+
+// ObserveHTTPRequest counts the request, the route is empty if not matched.
+func ObserveHTTPRequest(route string, statusCode int, d time.Duration) {
+
+	if route == "" {
+		route = unmatchedRoute
+	}
+
+	status := strconv.Itoa(statusCode)
+
+	httpRequests.WithLabelValues(route, status).Inc()
+	httpDuration.WithLabelValues(route, status).Observe(d.Seconds())
+}
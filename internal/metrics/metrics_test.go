@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"effective-mobile-go/internal/reqinfo"
+)
+
+func TestObserveHTTPRequest(t *testing.T) {
+
+	info := &reqinfo.Info{}
+	ctx := reqinfo.NewContext(context.Background(), info)
+
+	h := Route("GET    /songs/{id}", http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/songs/1", nil).WithContext(ctx))
+
+	ObserveHTTPRequest(info.Route, 404, time.Millisecond)
+	ObserveHTTPRequest("", 404, time.Millisecond)
+
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues("GET /songs/{id}", "404")); got != 1 {
+		t.Errorf("requests of the route = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues(unmatchedRoute, "404")); got != 1 {
+		t.Errorf("unmatched requests = %v, want 1", got)
+	}
+}
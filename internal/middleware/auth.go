@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"effective-mobile-go/internal/auth"
+	"effective-mobile-go/internal/config"
+	"effective-mobile-go/internal/logger"
+	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/problem"
+	"effective-mobile-go/internal/reqinfo"
+)
+
+type Authenticator interface {
+	Authenticate(*http.Request) (model.Principal, error)
+}
+
+// Auth puts the authenticated caller into the request context and its logger. Invalid
+// credentials are always rejected, missing ones only if required, otherwise the caller
+// gets the anonymous role.
+func Auth(h http.Handler, authn Authenticator, cfg config.Auth) http.HandlerFunc {
+
+	anonymous := model.Principal{
+		Method: model.AuthAnonymous,
+		Name:   "anonymous",
+	}
+
+	if cfg.AnonymousRole != "" {
+		anonymous.Roles = []string{cfg.AnonymousRole}
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		log := logger.GetLoggerFromContextOrDefault(r.Context())
+
+		p, err := authn.Authenticate(r)
+
+		if errors.Is(err, auth.ErrNoCredentials) && !cfg.Required {
+			reqinfo.FromContext(r.Context()).Principal = anonymous.Name
+			h.ServeHTTP(w, r.WithContext(auth.ContextWithPrincipal(r.Context(), anonymous)))
+			return
+		}
+
+		if err != nil {
+			log.Debug("authentication failed", "error", err)
+
+			if errors.Is(err, auth.ErrNoCredentials) || errors.Is(err, model.ErrUnauthorized) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+				writeError(w, r, model.ErrUnauthorized)
+			} else {
+				log.Error("can't authenticate", "error", err)
+				writeError(w, r, model.ErrInternalError)
+			}
+			return
+		}
+
+		reqinfo.FromContext(r.Context()).Principal = p.Name
+
+		log = log.With("principal", p.Name, "authMethod", p.Method)
+		log.Debug("authenticated", "subject", p.Subject, "roles", p.Roles)
+
+		ctx := auth.ContextWithPrincipal(r.Context(), p)
+		ctx = logger.ContextWithLogger(ctx, log)
+
+		h.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+// writeError writes the error in the same format as the handlers do.
+func writeError(w http.ResponseWriter, r *http.Request, err *model.Error) {
+	problem.Write(w, r, err)
+}
@@ -2,31 +2,72 @@ package middleware
 
 import (
 	"log/slog"
-	"math/rand/v2"
 	"net/http"
-	"runtime/debug"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
 
 	"effective-mobile-go/internal/logger"
+	"effective-mobile-go/internal/metrics"
+	"effective-mobile-go/internal/reqinfo"
 )
 
+// Logging logs the request and measures it. The request ID is taken from X-Request-ID
+// header or generated, and is sent back in the same header.
 func Logging(h http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
-		log := slog.Default().With("httpReqID", rand.Uint64())
+		start := time.Now()
+
+		info := &reqinfo.Info{ID: r.Header.Get(reqinfo.Header)}
+		if !reqinfo.IsValidID(info.ID) {
+			info.ID = reqinfo.NewID()
+		}
+
+		w.Header().Set(reqinfo.Header, info.ID)
+
+		log := slog.Default().With("requestID", info.ID)
+
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			log = log.With("traceID", sc.TraceID().String(), "spanID", sc.SpanID().String())
+		}
 
 		url := r.URL.String()
 		log.Debug("http request begin", "fromAddr", r.RemoteAddr, "method", r.Method, "url", url)
 
-		w = newWriteHeaderHook(w, func(statusCode int) {
-			log.Debug("http request end", "statusCode", statusCode, "url", url)
+		statusCode := http.StatusOK // if the handler writes nothing
+		hook := newWriteHeaderHook(w, func(code int) {
+			statusCode = code
+			log.Debug("http request end", "statusCode", code, "url", url)
 		})
+		w = hook
 
 		ctx := logger.ContextWithLogger(r.Context(), log)
+		ctx = reqinfo.NewContext(ctx, info)
 		r = r.WithContext(ctx)
 
 		defer func() {
-			if p := recover(); p != nil {
-				log.Error("*** panic recovered ***", "panic", p, "stack", debug.Stack())
+			p := recover()
+			if p != nil && !hook.flag {
+				statusCode = http.StatusInternalServerError // Recovery is not installed
+			}
+
+			latency := time.Since(start)
+			metrics.ObserveHTTPRequest(info.Route, statusCode, latency)
+
+			log.Info("http request",
+				"method", r.Method,
+				"route", info.Route,
+				"path", r.URL.Path,
+				"status", statusCode,
+				"latency", latency,
+				"bytes", hook.bytes,
+				"principal", info.Principal,
+				"fromAddr", r.RemoteAddr,
+			)
+
+			if p != nil {
+				panic(p) // it is the business of Recovery or http.Server
 			}
 		}()
 
@@ -36,8 +77,9 @@ func Logging(h http.Handler) http.HandlerFunc {
 
 type writeHeaderHook struct {
 	http.ResponseWriter
-	hook func(statusCode int)
-	flag bool // need to use atomic.Bool for thread safety
+	hook  func(statusCode int)
+	flag  bool // need to use atomic.Bool for thread safety
+	bytes int64
 }
 
 func newWriteHeaderHook(w http.ResponseWriter, hook func(statusCode int)) *writeHeaderHook {
@@ -57,5 +99,12 @@ func (rw *writeHeaderHook) WriteHeader(statusCode int) {
 
 func (rw *writeHeaderHook) Write(b []byte) (int, error) {
 	rw.WriteHeader(http.StatusOK)
-	return rw.ResponseWriter.Write(b)
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer.
+func (rw *writeHeaderHook) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
 }
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"effective-mobile-go/internal/reqinfo"
+)
+
+func TestLogging_requestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		wantSame bool
+	}{
+		{"honoured", "abc-123", true},
+		{"generated", "", false},
+		{"invalid is replaced", "bad id\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var seen string
+			h := Logging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = reqinfo.FromContext(r.Context()).ID
+			}))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "http://localhost/test", nil)
+			if tt.incoming != "" {
+				r.Header.Set(reqinfo.Header, tt.incoming)
+			}
+
+			h.ServeHTTP(w, r)
+
+			got := w.Header().Get(reqinfo.Header)
+			if got == "" || got != seen {
+				t.Fatalf("response ID = %q, handler ID = %q, want the same non-empty", got, seen)
+			}
+			if (got == tt.incoming) != tt.wantSame {
+				t.Fatalf("response ID = %q, incoming %q, want same = %v", got, tt.incoming, tt.wantSame)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"effective-mobile-go/internal/auth"
+	"effective-mobile-go/internal/config"
+	"effective-mobile-go/internal/logger"
+	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/ratelimit"
+)
+
+// RateLimiter limits requests per client: the authenticated ones are told by the
+// principal, the anonymous ones by IP.
+type RateLimiter struct {
+	store ratelimit.Store
+	rates atomic.Pointer[map[ratelimit.Class]config.Rate]
+}
+
+func NewRateLimiter(store ratelimit.Store, cfg config.RateLimit) *RateLimiter {
+	l := &RateLimiter{store: store}
+	l.SetRates(cfg)
+	return l
+}
+
+// SetRates changes the rates of the requests taken after the call.
+func (l *RateLimiter) SetRates(cfg config.RateLimit) {
+	l.rates.Store(&map[ratelimit.Class]config.Rate{
+		ratelimit.Read:   cfg.Read,
+		ratelimit.Write:  cfg.Write,
+		ratelimit.Remote: cfg.Remote,
+	})
+}
+
+// Limit limits the requests of the route class. It sends RateLimit-Limit, RateLimit-Remaining
+// and RateLimit-Reset headers, and Retry-After with 429 if the limit is exceeded. If the
+// store fails, the request is let through.
+func (l *RateLimiter) Limit(class ratelimit.Class, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		rate := (*l.rates.Load())[class]
+		if rate.Count == 0 {
+			h.ServeHTTP(w, r) // unlimited
+			return
+		}
+
+		log := logger.GetLoggerFromContextOrDefault(r.Context())
+		key := string(class) + ":" + clientKey(r)
+
+		res, err := l.store.Take(r.Context(), key, rate)
+		if err != nil {
+			log.Error("can't take rate limit token, let it through", "error", err, "key", key)
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		w.Header().Set("RateLimit-Reset", seconds(res.Reset))
+
+		if !res.Allowed {
+			log.Debug("rate limit exceeded", "key", key, "retryAfter", res.RetryAfter)
+			w.Header().Set("Retry-After", seconds(res.RetryAfter))
+			writeError(w, r, model.ErrTooManyRequests)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+func clientKey(r *http.Request) string {
+
+	if p, ok := auth.PrincipalFromContext(r.Context()); ok && p.Method != model.AuthAnonymous {
+		return p.Method + ":" + p.Subject
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+// seconds rounds up, so that the client does not retry too early.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"effective-mobile-go/internal/auth"
+	"effective-mobile-go/internal/config"
+	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/ratelimit"
+)
+
+// recordingStore records the keys and fails if err is set.
+type recordingStore struct {
+	ratelimit.Store
+	keys []string
+	err  error
+}
+
+func (s *recordingStore) Take(ctx context.Context, key string, rate ratelimit.Rate) (ratelimit.Result, error) {
+	s.keys = append(s.keys, key)
+	if s.err != nil {
+		return ratelimit.Result{}, s.err
+	}
+	return s.Store.Take(ctx, key, rate)
+}
+
+func TestRateLimiter_Limit(t *testing.T) {
+
+	store := &recordingStore{Store: ratelimit.NewMemory()}
+	l := NewRateLimiter(store, config.RateLimit{Read: config.Rate{Count: 2, Period: time.Minute}})
+
+	h := l.Limit(ratelimit.Read, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	request := func(remoteAddr string, p *model.Principal) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/songs", nil)
+		r.RemoteAddr = remoteAddr
+		if p != nil {
+			r = r.WithContext(auth.ContextWithPrincipal(r.Context(), *p))
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	for i, remaining := range []string{"1", "0"} {
+		w := request("192.0.2.1:1234", nil)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("request %d: status code = %d, want 204", i, w.Code)
+		}
+		if got := w.Header().Get("RateLimit-Limit"); got != "2" {
+			t.Errorf("request %d: RateLimit-Limit = %q, want 2", i, got)
+		}
+		if got := w.Header().Get("RateLimit-Remaining"); got != remaining {
+			t.Errorf("request %d: RateLimit-Remaining = %q, want %s", i, got, remaining)
+		}
+		if got := w.Header().Get("RateLimit-Reset"); got == "" || got == "0" {
+			t.Errorf("request %d: RateLimit-Reset = %q, want > 0", i, got)
+		}
+	}
+
+	// the same IP from another port is the same client
+	w := request("192.0.2.1:4321", nil)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status code = %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After = %q, want 30", got)
+	}
+	if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/problem+json") {
+		t.Errorf("content type = %q, want problem details", got)
+	}
+
+	// the authenticated clients are told by the principal, not by IP
+	apiKey := &model.Principal{Method: model.AuthAPIKey, Subject: "7"}
+	anonymous := &model.Principal{Method: model.AuthAnonymous}
+
+	if w := request("192.0.2.1:1234", apiKey); w.Code != http.StatusNoContent {
+		t.Errorf("api key: status code = %d, want 204", w.Code)
+	}
+	if w := request("192.0.2.1:1234", anonymous); w.Code != http.StatusTooManyRequests {
+		t.Errorf("anonymous: status code = %d, want 429", w.Code)
+	}
+	if w := request("192.0.2.2:1234", nil); w.Code != http.StatusNoContent {
+		t.Errorf("other IP: status code = %d, want 204", w.Code)
+	}
+
+	want := []string{"read:ip:192.0.2.1", "read:ip:192.0.2.1", "read:ip:192.0.2.1", "read:apikey:7", "read:ip:192.0.2.1", "read:ip:192.0.2.2"}
+	if strings.Join(store.keys, ",") != strings.Join(want, ",") {
+		t.Errorf("keys = %v, want %v", store.keys, want)
+	}
+}
+
+func TestRateLimiter_Limit_storeFails(t *testing.T) {
+
+	store := &recordingStore{err: errors.New("db is down")}
+	l := NewRateLimiter(store, config.RateLimit{Write: config.Rate{Count: 1, Period: time.Minute}})
+
+	h := l.Limit(ratelimit.Write, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for i := range 3 {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("POST", "/songs", nil))
+
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("request %d: status code = %d, want 204", i, w.Code)
+		}
+		if got := w.Header().Get("RateLimit-Limit"); got != "" {
+			t.Errorf("request %d: RateLimit-Limit = %q, want none", i, got)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"effective-mobile-go/internal/logger"
+	"effective-mobile-go/internal/metrics"
+	"effective-mobile-go/internal/model"
+)
+
+// Recovery turns the panic of the handler into 500 response. If the response has already
+// been started, it can't be replaced, so the connection is aborted: it's the only way to
+// let the client know that the response is broken. http.ErrAbortHandler is passed as is.
+func Recovery(h http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		hook := newWriteHeaderHook(w, func(int) {})
+
+		defer func() {
+			p := recover()
+			switch {
+			case p == nil:
+				return
+			case p == http.ErrAbortHandler:
+				panic(p) // the handler deliberately breaks the response
+			}
+
+			log := logger.GetLoggerFromContextOrDefault(r.Context())
+			log.Error("*** panic recovered ***", "panic", p, "stack", debug.Stack())
+
+			metrics.PanicRecovered()
+
+			if hook.flag {
+				log.Error("response has already been started, abort it")
+				panic(http.ErrAbortHandler)
+			}
+
+			writeError(w, r, model.ErrInternalError)
+		}()
+
+		h.ServeHTTP(hook, r)
+	}
+}
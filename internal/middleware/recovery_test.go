@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"effective-mobile-go/internal/problem"
+	"effective-mobile-go/internal/reqinfo"
+)
+
+func TestRecovery(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		wantPanic any
+		wantCode  int
+		wantError bool // problem details in the body
+	}{
+		{
+			"no panic",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+			},
+			nil,
+			http.StatusCreated,
+			false,
+		},
+		{
+			"panic before writing",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Test", "1")
+				panic("boom")
+			},
+			nil,
+			http.StatusInternalServerError,
+			true,
+		},
+		{
+			"panic with error value",
+			func(w http.ResponseWriter, r *http.Request) {
+				var m map[string]int
+				m["x"] = 1
+			},
+			nil,
+			http.StatusInternalServerError,
+			true,
+		},
+		{
+			"panic after writing",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"songs":[`))
+				panic("boom")
+			},
+			http.ErrAbortHandler,
+			http.StatusOK,
+			false,
+		},
+		{
+			"abort is passed as is",
+			func(w http.ResponseWriter, r *http.Request) {
+				panic(http.ErrAbortHandler)
+			},
+			http.ErrAbortHandler,
+			http.StatusOK, // nothing has been written
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "http://localhost/test", nil)
+			r = r.WithContext(reqinfo.NewContext(r.Context(), &reqinfo.Info{ID: "req-1"}))
+
+			func() {
+				defer func() {
+					if p := recover(); p != tt.wantPanic {
+						t.Fatalf("panic = %v, want %v", p, tt.wantPanic)
+					}
+				}()
+				Recovery(tt.handler).ServeHTTP(w, r)
+			}()
+
+			if got, want := w.Code, tt.wantCode; got != want {
+				t.Fatalf("status code = %d, want %d", got, want)
+			}
+
+			if !tt.wantError {
+				return
+			}
+
+			if got, want := w.Header().Get("content-type"), problem.ContentType; got != want {
+				t.Fatalf("content-type = %q, want %q", got, want)
+			}
+
+			var resp problem.Details
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Status != http.StatusInternalServerError || resp.RequestID != "req-1" {
+				t.Fatalf("problem = %+v, want status 500 and request ID req-1", resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// The roles are ordered, a role grants everything the lower ones do.
+const (
+	RoleReader = "reader" // list and read songs and lyrics
+	RoleEditor = "editor" // create and update
+	RoleAdmin  = "admin"  // delete, import and manage API keys
+)
+
+var roleRank = map[string]int{
+	RoleReader: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+func IsValidRole(role string) bool {
+	return roleRank[role] != 0
+}
+
+const (
+	AuthAPIKey    = "apikey"
+	AuthJWT       = "jwt"
+	AuthAnonymous = "anonymous"
+)
+
+// Principal is the authenticated caller.
+type Principal struct {
+	Method  string // AuthAPIKey, AuthJWT or AuthAnonymous
+	Subject string // API key ID or JWT subject
+	Name    string
+	Roles   []string
+}
+
+// HasRole reports whether the principal has the role or a higher one.
+func (p Principal) HasRole(role string) bool {
+	want := roleRank[role]
+	return want != 0 && slices.ContainsFunc(p.Roles, func(r string) bool {
+		return roleRank[r] >= want
+	})
+}
+
+// APIKey is the stored API key. The key itself is not stored, only its hash.
+type APIKey struct {
+	ID        uint64
+	Name      string
+	Prefix    string // first characters of the key to recognize it
+	Roles     []string
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"bytes"
+	"encoding"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -54,7 +55,18 @@ func (d *Date) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// UnmarshalText implements encoding.TextUnmarshaler (the one of time.Time expects RFC 3339).
+func (d *Date) UnmarshalText(b []byte) error {
+	v, err := ParseDate(string(b))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
 var (
-	_ json.Marshaler   = Date{}
-	_ json.Unmarshaler = (*Date)(nil)
+	_ json.Marshaler           = Date{}
+	_ json.Unmarshaler         = (*Date)(nil)
+	_ encoding.TextUnmarshaler = (*Date)(nil)
 )
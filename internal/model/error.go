@@ -1,22 +1,101 @@
 package model
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+)
 
+// Error is the error shown to the client. The predefined errors below are the kinds of
+// errors; the detailed ones are derived from them by the With* methods and Wrap, and
+// errors.Is reports them as the kind they were derived from.
 type Error struct {
-	code int
-	msg  string
+	code   int
+	msg    string
+	detail string
+	fields []FieldError
+	cause  error
+}
+
+// FieldError names the invalid field of the request and the reason.
+type FieldError struct {
+	Field  string
+	Reason string
 }
 
 func (e *Error) Error() string {
-	return fmt.Sprintf("%d %s", e.code, e.msg)
+	s := fmt.Sprintf("%d %s", e.code, e.msg)
+	if e.detail != "" {
+		s += ": " + e.detail
+	}
+	if e.cause != nil {
+		s += ": " + e.cause.Error()
+	}
+	return s
 }
 
 func (e *Error) Code() int {
 	return e.code
 }
 
+// Title is the short description of the kind of the error.
+func (e *Error) Title() string {
+	return e.msg
+}
+
+// Detail is the explanation of this occurrence of the error (may be empty).
+func (e *Error) Detail() string {
+	return e.detail
+}
+
+// Fields are the invalid fields of the request.
+func (e *Error) Fields() []FieldError {
+	return e.fields
+}
+
+// Unwrap returns the cause. It's never shown to the client.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether the target is the same kind of error.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.code == e.code && t.msg == e.msg
+}
+
+// WithDetail returns a copy of the error with the detail.
+func (e *Error) WithDetail(detail string) *Error {
+	c := e.clone()
+	c.detail = detail
+	return c
+}
+
+// WithField returns a copy of the error with the invalid field added.
+func (e *Error) WithField(field, reason string) *Error {
+	c := e.clone()
+	c.fields = append(c.fields, FieldError{field, reason})
+	return c
+}
+
+// Wrap returns a copy of the error with the cause.
+func (e *Error) Wrap(cause error) *Error {
+	c := e.clone()
+	c.cause = cause
+	return c
+}
+
+func (e *Error) clone() *Error {
+	c := *e
+	c.fields = slices.Clip(c.fields) // append must not share the array
+	return &c
+}
+
 var (
-	ErrBadRequest    = &Error{400, "bad request"}
-	ErrNotFound      = &Error{404, "not fond"}
-	ErrInternalError = &Error{500, "internal error"}
+	ErrBadRequest      = &Error{code: 400, msg: "bad request"}
+	ErrUnauthorized    = &Error{code: 401, msg: "unauthorized"}
+	ErrForbidden       = &Error{code: 403, msg: "forbidden"}
+	ErrNotFound        = &Error{code: 404, msg: "not found"}
+	ErrTooLarge        = &Error{code: 413, msg: "request entity too large"}
+	ErrTooManyRequests = &Error{code: 429, msg: "too many requests"}
+	ErrInternalError   = &Error{code: 500, msg: "internal error"}
 )
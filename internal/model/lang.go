@@ -0,0 +1,43 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+var errInvalidLang = errors.New("invalid language code")
+
+// ParseLang validates a BCP 47 like language code (en, pt-BR, zh-Hant-TW)
+// and returns it in lower case.
+func ParseLang(s string) (string, error) {
+
+	if len(s) == 0 || len(s) > 35 {
+		return "", errInvalidLang
+	}
+
+	for i, part := range strings.Split(s, "-") {
+
+		if len(part) == 0 || len(part) > 8 || (i == 0 && (len(part) < 2 || len(part) > 3)) {
+			return "", errInvalidLang
+		}
+
+		for _, c := range part {
+			switch {
+			case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+			case '0' <= c && c <= '9' && i > 0:
+			default:
+				return "", errInvalidLang
+			}
+		}
+	}
+
+	return strings.ToLower(s), nil
+}
+
+// BaseLang returns the primary language subtag: "pt-br" -> "pt".
+func BaseLang(lang string) string {
+	if i := strings.IndexByte(lang, '-'); i >= 0 {
+		return lang[:i]
+	}
+	return lang
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // TODO: easyjson
 
 type SongDetail struct {
@@ -12,9 +14,30 @@ type SongDetail struct {
 }
 
 type GetSongTextRequest struct {
-	ID     uint64  `json:"id,omitempty"`
-	Offset *uint64 `json:"offset,omitempty"`
-	Limit  *uint64 `json:"limit,omitempty"`
+	ID              uint64   `json:"id,omitempty"`
+	Lang            string   `json:"lang,omitempty"`
+	AcceptLanguages []string `json:"acceptLanguages,omitempty"`
+	SideBySide      bool     `json:"sideBySide,omitempty"`
+	Offset          *uint64  `json:"offset,omitempty"`
+	Limit           *uint64  `json:"limit,omitempty"`
+}
+
+type SongText struct {
+	Lang       string   `json:"lang,omitempty"`
+	Original   bool     `json:"original,omitempty"`
+	Translator string   `json:"translator,omitempty"`
+	Verses     []string `json:"verses,omitempty"`
+
+	// OriginalVerses is filled for side-by-side output only and is aligned with Verses.
+	OriginalVerses []string `json:"originalVerses,omitempty"`
+}
+
+type SongLyrics struct {
+	SongID     uint64 `json:"songId,omitempty"`
+	Lang       string `json:"lang,omitempty"`
+	Original   bool   `json:"original,omitempty"`
+	Translator string `json:"translator,omitempty"`
+	Text       string `json:"text,omitempty"`
 }
 
 type SongFilters struct {
@@ -23,6 +46,7 @@ type SongFilters struct {
 	Release *Date   `json:"release,omitempty"`
 	Text    *string `json:"text,omitempty"`
 	Link    *string `json:"link,omitempty"`
+	Lang    *string `json:"lang,omitempty"`
 	Offset  *uint64 `json:"offset,omitempty"`
 	Limit   *uint64 `json:"limit,omitempty"`
 }
@@ -35,3 +59,92 @@ type SongUpdate struct {
 	Text    *string `json:"text,omitempty"`
 	Link    *string `json:"link,omitempty"`
 }
+
+type SongStats struct {
+	SongID      uint64        `json:"songId,omitempty"`
+	Verses      int           `json:"verses"`
+	Lines       int           `json:"lines"`
+	Words       int           `json:"words"`
+	UniqueWords int           `json:"uniqueWords"`
+	UniqueRatio float64       `json:"uniqueRatio"`
+	Lang        string        `json:"lang,omitempty"`
+	ReadingTime time.Duration `json:"readingTime"`
+	WordFreq    []WordCount   `json:"wordFreq,omitempty"`
+}
+
+type WordCount struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+type ImportRow struct {
+	Row     int    `json:"row"`
+	Group   string `json:"group,omitempty"`
+	Song    string `json:"song,omitempty"`
+	Release Date   `json:"release,omitempty"`
+	Text    string `json:"text,omitempty"`
+	Link    string `json:"link,omitempty"`
+}
+
+type ImportStatus string
+
+const (
+	ImportCreated  ImportStatus = "created"
+	ImportExisting ImportStatus = "existing"
+	ImportValid    ImportStatus = "valid" // dry-run only
+	ImportFailed   ImportStatus = "failed"
+)
+
+type ImportOptions struct {
+	DryRun      bool `json:"dryRun,omitempty"`
+	Concurrency int  `json:"concurrency,omitempty"`
+}
+
+type ImportResult struct {
+	Row    int          `json:"row"`
+	Group  string       `json:"group,omitempty"`
+	Song   string       `json:"song,omitempty"`
+	ID     uint64       `json:"id,omitempty"`
+	Status ImportStatus `json:"status"`
+	Reason string       `json:"reason,omitempty"`
+}
+
+type EnrichStatus string
+
+const (
+	EnrichUpdated   EnrichStatus = "updated"
+	EnrichChanged   EnrichStatus = "changed" // dry-run only: would be updated
+	EnrichUnchanged EnrichStatus = "unchanged"
+	EnrichFailed    EnrichStatus = "failed"
+)
+
+type EnrichOptions struct {
+	DryRun      bool `json:"dryRun,omitempty"`
+	Overwrite   bool `json:"overwrite,omitempty"` // replace the present fields too
+	Concurrency int  `json:"concurrency,omitempty"`
+}
+
+type EnrichResult struct {
+	ID     uint64       `json:"id"`
+	Group  string       `json:"group,omitempty"`
+	Song   string       `json:"song,omitempty"`
+	Status EnrichStatus `json:"status"`
+	Fields []string     `json:"fields,omitempty"` // the changed fields
+	Reason string       `json:"reason,omitempty"`
+}
+
+type BatchStatus string
+
+const (
+	BatchOK      BatchStatus = "ok"
+	BatchFailed  BatchStatus = "failed"
+	BatchAborted BatchStatus = "aborted" // atomic mode only: not applied because of another item
+)
+
+type BatchResult struct {
+	Index  int         `json:"index"`
+	ID     uint64      `json:"id,omitempty"`
+	Status BatchStatus `json:"status"`
+	Reason string      `json:"reason,omitempty"`
+	Song   *SongDetail `json:"song,omitempty"`
+}
@@ -0,0 +1,10 @@
+package model
+
+import "database/sql"
+
+// TxOptions are options of a unit of work (see LocalRepo.WithTx).
+type TxOptions struct {
+	Isolation sql.IsolationLevel // sql.LevelDefault is READ COMMITTED for Postgres
+	ReadOnly  bool
+	Retries   int // how many times to retry on serialization failure or deadlock
+}
@@ -0,0 +1,322 @@
+// Package openapi validates the requests and the responses of the API against the
+// generated Swagger spec (docs/swagger.json), which is converted to OpenAPI 3 for that.
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+
+	"effective-mobile-go/internal/logger"
+	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/problem"
+)
+
+// Validator validates the requests and the responses of the routes of the spec. The
+// requests to the paths which are not in the spec are passed as is.
+type Validator struct {
+	doc      *openapi3.T
+	router   routers.Router
+	basePath string
+
+	// OnResponseError is called if the response doesn't follow the spec. The response
+	// has already been written. By default the error is logged.
+	OnResponseError func(*http.Request, error)
+}
+
+// New returns the validator of the Swagger 2.0 spec. The servers of the spec are
+// dropped (the base path is stripped from the request path instead), so the host of
+// the request doesn't matter.
+func New(swagger []byte) (*Validator, error) {
+	const op = "openapi.New"
+
+	var doc2 openapi2.T
+	if err := json.Unmarshal(swagger, &doc2); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	doc, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	doc.Servers = nil
+
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Validator{
+		doc:             doc,
+		router:          router,
+		basePath:        strings.TrimSuffix(doc2.BasePath, "/"),
+		OnResponseError: logResponseError,
+	}, nil
+}
+
+// Operations returns the operations of the spec as "METHOD /path" (the path is relative
+// to the base path, as in the route patterns of the handler).
+func (v *Validator) Operations() []string {
+
+	var ops []string
+
+	for path, item := range v.doc.Paths.Map() {
+		for method := range item.Operations() {
+			ops = append(ops, method+" "+path)
+		}
+	}
+
+	sort.Strings(ops)
+	return ops
+}
+
+// Handler responds 400 with the problem details to the requests which don't follow the
+// spec. If responses is true, the responses are validated too (they are buffered, so
+// it's for dev and test only).
+func (v *Validator) Handler(h http.Handler, responses bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		path, ok := strings.CutPrefix(r.URL.Path, v.basePath)
+		if !ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		// the spec paths are relative to the base path
+		sr := *r
+		sr.URL = new(url.URL)
+		*sr.URL = *r.URL
+		sr.URL.Path, sr.URL.RawPath = path, ""
+
+		route, pathParams, err := v.router.FindRoute(&sr)
+		if err != nil {
+			h.ServeHTTP(w, r) // not in the spec, let the handler respond 404 or 405
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    &sr,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				ExcludeRequestBody:  isFileBody(route.Operation),
+				MultiError:          true,
+				SkipSettingDefaults: true,
+				AuthenticationFunc:  openapi3filter.NoopAuthenticationFunc, // see middleware.Auth
+			},
+		}
+
+		if err := validateRequest(w, r, &sr, input); err != nil {
+
+			logger.GetLoggerFromContextOrDefault(r.Context()).Debug("request doesn't follow the spec", "error", err)
+
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				problem.Write(w, r, model.ErrTooLarge.WithDetail(fmt.Sprintf("body must be at most %d bytes", tooLarge.Limit)))
+				return
+			}
+
+			problem.Write(w, r, badRequest(err))
+			return
+		}
+
+		if !responses {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(rec, r)
+
+		if err := validateResponse(r.Context(), input, rec); err != nil {
+			v.OnResponseError(r, err)
+		}
+	})
+}
+
+// maxBodySize is the limit of the request bodies which are validated, so they are read
+// into memory. The file bodies are not, they are streamed to the handler.
+const maxBodySize = 8 << 20
+
+// validateRequest validates the spec request sr, the copy of r. The body of r is read
+// (unless it's excluded from the validation) and restored.
+func validateRequest(w http.ResponseWriter, r, sr *http.Request, input *openapi3filter.RequestValidationInput) error {
+
+	if r.Body != nil && r.Body != http.NoBody && !input.Options.ExcludeRequestBody {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			return err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sr.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	return openapi3filter.ValidateRequest(r.Context(), input)
+}
+
+// isFileBody reports whether the request body is a file (a string in the spec), whose
+// format is checked by the handler.
+func isFileBody(op *openapi3.Operation) bool {
+
+	if op.RequestBody == nil || op.RequestBody.Value == nil {
+		return false
+	}
+
+	for _, mt := range op.RequestBody.Value.Content {
+		if mt.Schema == nil || mt.Schema.Value == nil || !mt.Schema.Value.Type.Is("string") {
+			return false
+		}
+	}
+
+	return true
+}
+
+func validateResponse(ctx context.Context, input *openapi3filter.RequestValidationInput, rec *recorder) error {
+
+	header := rec.Header().Clone()
+	opts := *input.Options
+	opts.IncludeResponseStatus = true
+
+	mediaType, _, _ := mime.ParseMediaType(header.Get("content-type"))
+	switch {
+	case mediaType == problem.ContentType:
+		header.Set("content-type", "application/json") // the spec doesn't know the problem details type
+	case mediaType != "application/json":
+		opts.ExcludeResponseBody = true // exports and files
+	case rec.status >= 400:
+		opts.ExcludeResponseBody = true // the legacy error format, see problem.PrefersLegacy
+	}
+
+	return openapi3filter.ValidateResponse(ctx, &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 rec.status,
+		Header:                 header,
+		Body:                   io.NopCloser(bytes.NewReader(rec.body.Bytes())),
+		Options:                &opts,
+	})
+}
+
+func logResponseError(r *http.Request, err error) {
+	logger.GetLoggerFromContextOrDefault(r.Context()).Error("response doesn't follow the spec",
+		slog.String("method", r.Method), slog.String("path", r.URL.Path), slog.Any("error", err))
+}
+
+// badRequest returns ErrBadRequest with the invalid fields of the validation error.
+func badRequest(err error) *model.Error {
+
+	bad := model.ErrBadRequest.Wrap(err)
+	var details []string
+
+	for _, err := range flatten(err) {
+
+		var reqErr *openapi3filter.RequestError
+		if !errors.As(err, &reqErr) {
+			details = append(details, err.Error())
+			continue
+		}
+
+		if reqErr.Parameter != nil {
+			bad = bad.WithField(reqErr.Parameter.Name, reason(reqErr))
+			continue
+		}
+
+		schemaErrs := flatten(reqErr.Err)
+		if len(schemaErrs) == 0 {
+			details = append(details, reqErr.Error())
+			continue
+		}
+
+		for _, err := range schemaErrs {
+			var schemaErr *openapi3.SchemaError
+			if errors.As(err, &schemaErr) && len(schemaErr.JSONPointer()) > 0 {
+				bad = bad.WithField(strings.Join(schemaErr.JSONPointer(), "."), schemaErr.Reason)
+			} else {
+				details = append(details, "body: "+err.Error())
+			}
+		}
+	}
+
+	if len(details) > 0 {
+		bad = bad.WithDetail(strings.Join(details, "; "))
+	}
+
+	return bad
+}
+
+// reason returns the reason of the parameter error without the parameter details.
+func reason(reqErr *openapi3filter.RequestError) string {
+
+	var schemaErr *openapi3.SchemaError
+	switch {
+	case errors.As(reqErr.Err, &schemaErr):
+		return schemaErr.Reason
+	case reqErr.Err != nil:
+		return reqErr.Err.Error()
+	}
+	return reqErr.Reason
+}
+
+func flatten(err error) []error {
+
+	if err == nil {
+		return nil
+	}
+
+	errs, ok := err.(openapi3.MultiError) // not errors.As: it would skip the RequestError
+	if !ok {
+		return []error{err}
+	}
+
+	var all []error
+	for _, err := range errs {
+		all = append(all, flatten(err)...)
+	}
+	return all
+}
+
+// recorder copies the response for the validation.
+type recorder struct {
+	http.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (w *recorder) WriteHeader(status int) {
+	if !w.written {
+		w.status = status
+		w.written = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recorder) Write(b []byte) (int, error) {
+	w.written = true
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
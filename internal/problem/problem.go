@@ -0,0 +1,150 @@
+// Package problem writes model.Error as RFC 7807 problem details. The legacy
+// {"error":{code,message}} format is written to the clients which prefer
+// application/json to application/problem+json in the Accept header.
+package problem
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/reqinfo"
+)
+
+const (
+	ContentType       = "application/problem+json"
+	LegacyContentType = "application/json"
+)
+
+// Details is the problem details object. The errors are only set for the invalid
+// requests.
+type Details struct {
+	Type      string       `json:"type" example:"about:blank"`
+	Title     string       `json:"title" example:"Bad Request"`
+	Status    int          `json:"status" example:"400"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty" example:"/api/v1/songs"`
+	RequestID string       `json:"requestId,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+type FieldError struct {
+	Field  string `json:"field" example:"limit"`
+	Reason string `json:"reason" example:"limit must be > 0"`
+}
+
+// Legacy is the error response of the legacy format.
+type Legacy struct {
+	Error LegacyError `json:"error,omitempty"`
+}
+
+type LegacyError struct {
+	Code      int    `json:"code,omitempty"`
+	Message   string `json:"message,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// New returns the problem details of the error which occurred on the request.
+func New(r *http.Request, e *model.Error) Details {
+
+	d := Details{
+		Type:      "about:blank",
+		Title:     http.StatusText(e.Code()),
+		Status:    e.Code(),
+		Detail:    e.Detail(),
+		Instance:  instance(r),
+		RequestID: reqinfo.FromContext(r.Context()).ID,
+	}
+
+	for _, f := range e.Fields() {
+		d.Errors = append(d.Errors, FieldError(f))
+	}
+
+	return d
+}
+
+// NewLegacy returns the legacy error response. The detail and the reasons are appended
+// to the message.
+func NewLegacy(r *http.Request, e *model.Error) Legacy {
+
+	msg := fmt.Sprintf("%d %s", e.Code(), e.Title())
+	if e.Detail() != "" {
+		msg += ": " + e.Detail()
+	}
+	for i, f := range e.Fields() {
+		if i == 0 {
+			msg += ": "
+		} else {
+			msg += "; "
+		}
+		msg += f.Reason
+	}
+
+	return Legacy{
+		Error: LegacyError{
+			Code:      e.Code(),
+			Message:   msg,
+			RequestID: reqinfo.FromContext(r.Context()).ID,
+		},
+	}
+}
+
+// Write writes the error in the format negotiated by the Accept header.
+func Write(w http.ResponseWriter, r *http.Request, e *model.Error) error {
+
+	var body any = New(r, e)
+	contentType := ContentType
+
+	if PrefersLegacy(r.Header.Get("Accept")) {
+		body = NewLegacy(r, e)
+		contentType = LegacyContentType
+	}
+
+	w.Header().Set("content-type", contentType) // it must be before WriteHeader
+	w.WriteHeader(e.Code())
+	return json.NewEncoder(w).Encode(body)
+}
+
+// PrefersLegacy reports whether application/json has the higher quality than
+// application/problem+json in the Accept header. Wildcards match both equally, so
+// problem details are the default.
+func PrefersLegacy(accept string) bool {
+
+	var qJSON, qProblem float64
+
+	for _, part := range strings.Split(accept, ",") {
+
+		mediaType, params, _ := strings.Cut(part, ";")
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if s, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if v, err := strconv.ParseFloat(s, 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case LegacyContentType:
+			qJSON = max(qJSON, q)
+		case ContentType:
+			qProblem = max(qProblem, q)
+		}
+	}
+
+	return qJSON > qProblem
+}
+
+// instance is the path of the request as the client sent it, i.e. before any prefix
+// was stripped.
+func instance(r *http.Request) string {
+	if u, err := url.ParseRequestURI(r.RequestURI); err == nil && u.Path != "" {
+		return u.EscapedPath()
+	}
+	return r.URL.EscapedPath()
+}
@@ -0,0 +1,63 @@
+package problem
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"effective-mobile-go/internal/model"
+)
+
+func TestPrefersLegacy(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"application/problem+json", false},
+		{"application/json", true},
+		{"Application/JSON; charset=utf-8", true},
+		{"application/json, application/problem+json", false},
+		{"application/json, application/problem+json;q=0.9", true},
+		{"application/json;q=0.5, application/problem+json", false},
+		{"text/html, application/json;q=0.9, */*;q=0.8", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			if got := PrefersLegacy(tt.accept); got != tt.want {
+				t.Fatalf("PrefersLegacy(%q) = %v, want %v", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestError_Is(t *testing.T) {
+
+	cause := errors.New("db is down")
+	err := fmt.Errorf("op: %w", model.ErrInternalError.Wrap(cause))
+
+	if !errors.Is(err, model.ErrInternalError) {
+		t.Fatal("the derived error must be the kind it was derived from")
+	}
+
+	if errors.Is(err, model.ErrNotFound) {
+		t.Fatal("the derived error must not be other kinds")
+	}
+
+	if !errors.Is(err, cause) {
+		t.Fatal("the cause must be in the chain")
+	}
+
+	base := model.ErrBadRequest.WithField("a", "a is bad")
+	x, y := base.WithField("b", "b is bad"), base.WithField("c", "c is bad")
+
+	if got := x.Fields()[1].Field; got != "b" {
+		t.Fatalf("derived errors share fields: got %q, want %q", got, "b")
+	}
+
+	if len(y.Fields()) != 2 || len(model.ErrBadRequest.Fields()) != 0 {
+		t.Fatal("the base errors must not be changed")
+	}
+}
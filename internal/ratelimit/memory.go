@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+// Memory is the in-memory store. Its limits are per instance.
+type Memory struct {
+	mu        sync.Mutex
+	tats      map[string]time.Time
+	lastSweep time.Time
+	now       func() time.Time // for tests
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		tats: map[string]time.Time{},
+		now:  time.Now,
+	}
+}
+
+func (m *Memory) Take(_ context.Context, key string, rate Rate) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	if now.Sub(m.lastSweep) > sweepInterval {
+		m.sweep(now)
+	}
+
+	tat, allowed := take(m.tats[key], now, rate)
+	m.tats[key] = tat
+
+	return result(allowed, tat, now, rate), nil
+}
+
+// sweep forgets the full buckets, they are the same as the missing ones.
+func (m *Memory) sweep(now time.Time) {
+	for key, tat := range m.tats {
+		if !tat.After(now) {
+			delete(m.tats, key)
+		}
+	}
+	m.lastSweep = now
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemory_Take(t *testing.T) {
+
+	now := time.Date(2025, 1, 29, 12, 0, 0, 0, time.UTC)
+
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	rate := Rate{Count: 3, Period: 3 * time.Second} // a token per second
+
+	take := func() Result {
+		t.Helper()
+		res, err := m.Take(context.Background(), "k", rate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	// the burst
+	for i, want := range []int{2, 1, 0} {
+		res := take()
+		if !res.Allowed || res.Remaining != want || res.Limit != 3 {
+			t.Fatalf("take %d = %+v, want allowed with %d remaining", i, res, want)
+		}
+	}
+
+	res := take()
+	if res.Allowed || res.Remaining != 0 || res.RetryAfter != time.Second || res.Reset != 3*time.Second {
+		t.Fatalf("over the limit = %+v, want not allowed, retry after 1s, reset in 3s", res)
+	}
+
+	// the denied take does not consume
+	now = now.Add(time.Second)
+	if res := take(); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("after refill = %+v, want allowed with 0 remaining", res)
+	}
+
+	// the bucket does not grow over its size
+	now = now.Add(time.Hour)
+	if res := take(); !res.Allowed || res.Remaining != 2 {
+		t.Fatalf("after long pause = %+v, want allowed with 2 remaining", res)
+	}
+
+	// the keys are independent
+	if res, _ := m.Take(context.Background(), "other", rate); !res.Allowed || res.Remaining != 2 {
+		t.Fatalf("other key = %+v, want allowed with 2 remaining", res)
+	}
+}
+
+func TestMemory_sweep(t *testing.T) {
+
+	now := time.Date(2025, 1, 29, 12, 0, 0, 0, time.UTC)
+
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	rate := Rate{Count: 10, Period: time.Second}
+
+	m.Take(context.Background(), "a", rate)
+	now = now.Add(2 * sweepInterval)
+	m.Take(context.Background(), "b", rate)
+
+	if _, ok := m.tats["a"]; ok {
+		t.Fatal("full bucket of a is not swept")
+	}
+	if _, ok := m.tats["b"]; !ok {
+		t.Fatal("bucket of b is swept")
+	}
+}
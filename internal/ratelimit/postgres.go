@@ -0,0 +1,39 @@
+package ratelimit
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// sweepProbability is the chance of deleting the full buckets on a take.
+const sweepProbability = 0.001
+
+type db interface {
+	// TakeRateLimit is GCRA in the DB. Returns TAT after the take and the DB time.
+	TakeRateLimit(_ context.Context, key string, interval, period time.Duration) (tat, now time.Time, allowed bool, err error)
+	DeleteFullRateLimits(context.Context) error
+}
+
+// Postgres is the store shared by the instances of the service.
+type Postgres struct {
+	db db
+}
+
+func NewPostgres(db db) Postgres {
+	return Postgres{db: db}
+}
+
+func (p Postgres) Take(ctx context.Context, key string, rate Rate) (Result, error) {
+
+	tat, now, allowed, err := p.db.TakeRateLimit(ctx, key, interval(rate), rate.Period)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if rand.Float64() < sweepProbability {
+		p.db.DeleteFullRateLimits(context.WithoutCancel(ctx)) // it logs the error itself
+	}
+
+	return result(allowed, tat, now, rate), nil
+}
@@ -0,0 +1,83 @@
+// Package ratelimit implements token bucket rate limiting. The bucket is kept as GCRA
+// (generic cell rate algorithm), which is the same token bucket stored as one timestamp:
+// the theoretical arrival time (TAT) when the bucket is full again. It makes the check
+// and the update one atomic operation, in memory and in the DB alike.
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"effective-mobile-go/internal/config"
+)
+
+type Rate = config.Rate
+
+// Class is the class of routes which are limited together.
+type Class string
+
+const (
+	Read   Class = "read"
+	Write  Class = "write"
+	Remote Class = "remote" // the routes calling the remote API
+)
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the bucket is full
+	RetryAfter time.Duration // if not allowed
+}
+
+// Store takes a token from the bucket of the key. The bucket holds rate.Count tokens and
+// is refilled at rate.Count per rate.Period.
+type Store interface {
+	Take(_ context.Context, key string, rate Rate) (Result, error)
+}
+
+// interval is the time of one token refill.
+func interval(rate Rate) time.Duration {
+	return rate.Period / time.Duration(rate.Count)
+}
+
+// take is GCRA. Returns the new TAT and whether the token has been taken (if not, TAT
+// is not changed).
+func take(tat, now time.Time, rate Rate) (time.Time, bool) {
+
+	newTAT := later(tat, now).Add(interval(rate))
+
+	if newTAT.Sub(now) > rate.Period {
+		return tat, false
+	}
+
+	return newTAT, true
+}
+
+// result returns the result of the take by TAT after it.
+func result(allowed bool, tat, now time.Time, rate Rate) Result {
+
+	t := interval(rate)
+	wait := max(tat.Sub(now), 0) // until the bucket is full
+
+	res := Result{
+		Allowed:   allowed,
+		Limit:     rate.Count,
+		Remaining: int((rate.Period - wait) / t),
+		Reset:     wait,
+	}
+
+	if !allowed {
+		res.Remaining = 0
+		res.RetryAfter = wait + t - rate.Period
+	}
+
+	return res
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,85 @@
+// Package cached_remoterepo is a caching decorator of the remote repo. It caches found songs
+// and, with shorter TTL, songs not found remotely, so that repeated lookups of them do not
+// hit the upstream every time.
+package cached_remoterepo
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"effective-mobile-go/internal/cache"
+	"effective-mobile-go/internal/config"
+	"effective-mobile-go/internal/logger"
+	"effective-mobile-go/internal/model"
+)
+
+const loggerGroup = "cached_remoterepo"
+
+type SongDetail = model.SongDetail
+
+type remoteRepo interface {
+	GetSong(context.Context, SongDetail) (SongDetail, error)
+}
+
+type result struct {
+	song     SongDetail
+	notFound bool
+}
+
+type RemoteRepo struct {
+	repo        remoteRepo
+	cache       *cache.Cache[string, result]
+	negativeTTL *atomic.Int64 // time.Duration
+}
+
+func New(repo remoteRepo, cfg config.Cache) RemoteRepo {
+	r := RemoteRepo{
+		repo:        repo,
+		cache:       cache.New[string, result](cfg.RemoteSize, cfg.RemoteTTL),
+		negativeTTL: new(atomic.Int64),
+	}
+	r.negativeTTL.Store(int64(cfg.RemoteNegativeTTL))
+	return r
+}
+
+// SetTTL changes the TTLs of the songs cached after the call. The size can't be changed.
+func (r RemoteRepo) SetTTL(cfg config.Cache) {
+	r.cache.SetTTL(cfg.RemoteTTL)
+	r.negativeTTL.Store(int64(cfg.RemoteNegativeTTL))
+}
+
+func (r RemoteRepo) GetSong(ctx context.Context, song SongDetail) (SongDetail, error) {
+	const op = "GetSong"
+
+	// the exact match: the found song has the group and the name as asked, which the
+	// caller stores and looks for
+	key := song.Group + "\x00" + song.Name
+
+	if res, ok := r.cache.Get(key); ok {
+
+		log := logger.GetLoggerFromContextOrDefault(ctx).WithGroup(loggerGroup)
+		log.Debug("cache hit", "op", op, "key", key, "notFound", res.notFound)
+
+		if res.notFound {
+			return SongDetail{}, model.ErrNotFound
+		}
+		return res.song, nil
+	}
+
+	found, err := r.repo.GetSong(ctx, song)
+
+	switch {
+	case err == nil:
+		r.cache.Set(key, result{song: found})
+	case errors.Is(err, model.ErrNotFound):
+		r.cache.SetWithTTL(key, result{notFound: true}, time.Duration(r.negativeTTL.Load()))
+	}
+
+	return found, err
+}
+
+func (r RemoteRepo) Stats() cache.Stats {
+	return r.cache.Stats()
+}
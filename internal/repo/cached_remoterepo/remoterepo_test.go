@@ -0,0 +1,43 @@
+package cached_remoterepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"effective-mobile-go/internal/config"
+)
+
+type echoRepo struct {
+	calls int
+}
+
+func (r *echoRepo) GetSong(_ context.Context, song SongDetail) (SongDetail, error) {
+	r.calls++
+	song.Text = "text"
+	return song, nil
+}
+
+func TestRemoteRepo_GetSong_spelling(t *testing.T) {
+
+	repo := &echoRepo{}
+	r := New(repo, config.Cache{RemoteSize: 10, RemoteTTL: time.Minute})
+
+	for _, song := range []SongDetail{
+		{Group: "Muse", Name: "Uprising"},
+		{Group: "muse", Name: "uprising"},
+		{Group: "Muse", Name: "Uprising"},
+	} {
+		got, err := r.GetSong(context.Background(), song)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Group != song.Group || got.Name != song.Name {
+			t.Errorf("song = %q / %q, want %q / %q", got.Group, got.Name, song.Group, song.Name)
+		}
+	}
+
+	if repo.calls != 2 {
+		t.Errorf("calls = %d, want 2", repo.calls)
+	}
+}
@@ -16,6 +16,10 @@ type RemoteRepo struct{}
 
 func New(_ config.RemoteAPI) RemoteRepo { return RemoteRepo{} }
 
+func (r RemoteRepo) Ping(context.Context) error { return nil }
+
+func (r RemoteRepo) SetConfig(config.RemoteAPI) {}
+
 func (r RemoteRepo) GetSong(ctx context.Context, song SongDetail) (SongDetail, error) {
 	const op = "GetSong"
 	var zero SongDetail
@@ -0,0 +1,142 @@
+package localrepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"effective-mobile-go/internal/model"
+)
+
+type APIKey = model.APIKey
+
+// CreateAPIKey сохраняет ключ по его хэшу. Возвращает ключ с присвоенными ID и временем создания.
+func (r LocalRepo) CreateAPIKey(ctx context.Context, key APIKey, hash []byte) (APIKey, error) {
+	x := newHelper(ctx, "CreateAPIKey")
+	var zero APIKey
+
+	const q = `
+		INSERT INTO api_key (name, prefix, hash, roles) VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	if key.Roles == nil {
+		key.Roles = []string{}
+	}
+
+	err := r.querier(ctx).QueryRowContext(ctx, q, key.Name, key.Prefix, hash, key.Roles).
+		Scan(&key.ID, &key.CreatedAt)
+
+	if err != nil {
+
+		x.Log().Error("can't query", "error", err, "query", q, "name", key.Name)
+		return zero, internalError(err)
+	}
+
+	return key, nil
+}
+
+// ListAPIKeys возвращает все ключи, в том числе отозванные.
+func (r LocalRepo) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	x := newHelper(ctx, "ListAPIKeys")
+
+	const q = `
+		SELECT id, name, prefix, to_json(roles), created_at, revoked_at
+		FROM api_key
+		ORDER BY id
+	`
+
+	rows, err := r.querier(ctx).QueryContext(ctx, q)
+	if err != nil {
+
+		x.Log().Error("can't query", "error", err, "query", q)
+		return nil, internalError(err)
+	}
+
+	defer rows.Close()
+
+	var list []APIKey
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+
+			x.Log().Error("can't scan", "error", err, "query", q)
+			return nil, internalError(err)
+		}
+
+		list = append(list, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		x.Log().Error("can't get next row", "error", err)
+		return nil, internalError(err)
+	}
+
+	return list, nil
+}
+
+// GetAPIKeyByHash возвращает действующий (не отозванный) ключ по его хэшу. Если такого
+// ключа нет, то возвращает ErrNotFound.
+func (r LocalRepo) GetAPIKeyByHash(ctx context.Context, hash []byte) (APIKey, error) {
+	x := newHelper(ctx, "GetAPIKeyByHash")
+	var zero APIKey
+
+	const q = `
+		SELECT id, name, prefix, to_json(roles), created_at, revoked_at
+		FROM api_key
+		WHERE hash = $1 AND revoked_at IS NULL
+	`
+
+	key, err := scanAPIKey(r.querier(ctx).QueryRowContext(ctx, q, hash))
+	if err != nil {
+
+		if err == sql.ErrNoRows {
+			return zero, ErrNotFound
+		}
+
+		x.Log().Error("can't query", "error", err, "query", q)
+		return zero, internalError(err)
+	}
+
+	return key, nil
+}
+
+// RevokeAPIKey отзывает ключ. Повторный отзыв не меняет время отзыва. Если ключа с указанным
+// ID нет в базе, то возвращает ErrNotFound.
+func (r LocalRepo) RevokeAPIKey(ctx context.Context, keyID uint64) error {
+	x := newHelper(ctx, "RevokeAPIKey")
+
+	const q = `UPDATE api_key SET revoked_at = coalesce(revoked_at, now()) WHERE id = $1`
+
+	res, err := r.querier(ctx).ExecContext(ctx, q, keyID)
+	if err != nil {
+
+		x.Log().Error("can't query", "error", err, "query", q, "keyID", keyID)
+		return internalError(err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func scanAPIKey(row interface{ Scan(...any) error }) (APIKey, error) {
+
+	var (
+		key   APIKey
+		roles []byte
+	)
+
+	if err := row.Scan(&key.ID, &key.Name, &key.Prefix, &roles, &key.CreatedAt, &key.RevokedAt); err != nil {
+		return key, err
+	}
+
+	if err := json.Unmarshal(roles, &key.Roles); err != nil {
+		return key, err
+	}
+
+	return key, nil
+}
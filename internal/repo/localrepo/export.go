@@ -0,0 +1,44 @@
+package localrepo
+
+import (
+	"context"
+)
+
+// ExportSongs выбирает песни по фильтрам и передает их по одной в fn по мере чтения из базы,
+// поэтому расход памяти не зависит от размера выборки. Если withText == false, то текст песен
+// не выбирается. Ошибка fn прерывает выборку и возвращается как есть.
+func (r LocalRepo) ExportSongs(ctx context.Context, req SongListFilters, withText bool, fn func(SongDetail) error) error {
+	x := newHelper(ctx, "ExportSongs")
+
+	q, values := listSongsQuery(req, withText)
+
+	rows, err := r.querier(ctx).QueryContext(ctx, q, values...)
+	if err != nil {
+
+		x.Log().Error("can't query", "error", err, "query", q, "req", req)
+		return internalError(err)
+	}
+
+	defer rows.Close()
+
+	var song SongDetail
+
+	for rows.Next() {
+		if err := rows.Scan(&song.ID, &song.Name, &song.Group, &song.Release.Time, &song.Link, &song.Text); err != nil {
+
+			x.Log().Error("can't scan", "error", err, "query", q)
+			return internalError(err)
+		}
+
+		if err := fn(song); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		x.Log().Error("can't get next row", "error", err)
+		return internalError(err)
+	}
+
+	return nil
+}
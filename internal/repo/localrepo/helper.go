@@ -25,3 +25,9 @@ func (x *helper) Log() *slog.Logger {
 	}
 	return x.log
 }
+
+// internalError возвращает ErrInternalError, обернув причину. Причина доступна через
+// errors.As (например, чтобы распознать конфликт сериализации), но клиенту не показывается.
+func internalError(cause error) error {
+	return ErrInternalError.Wrap(cause)
+}
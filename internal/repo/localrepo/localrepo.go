@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"strings"
 
+	"effective-mobile-go/internal/cache"
+	"effective-mobile-go/internal/config"
 	"effective-mobile-go/internal/model"
 )
 
@@ -15,12 +17,14 @@ var (
 )
 
 type LocalRepo struct {
-	db *sql.DB
+	db    *sql.DB
+	songs *cache.Cache[uint64, SongDetail] // read-through cache of GetSong
 }
 
-func New(db *sql.DB) LocalRepo {
+func New(db *sql.DB, cfg config.Cache) LocalRepo {
 	return LocalRepo{
-		db: db,
+		db:    db,
+		songs: cache.New[uint64, SongDetail](cfg.SongSize, cfg.SongTTL),
 	}
 }
 
@@ -60,22 +64,32 @@ func (r LocalRepo) CreateSong(ctx context.Context, song SongDetail) (SongDetail,
 		FROM ins_or_sel_song AS s, ins_or_sel_group AS g
 	`
 
-	err := r.db.QueryRowContext(ctx, q, song.Name, song.Group, song.Release.Time, song.Text, song.Link).
+	err := r.querier(ctx).QueryRowContext(ctx, q, song.Name, song.Group, song.Release.Time, song.Text, song.Link).
 		Scan(&song.ID, &song.Name, &song.Group, &song.Release.Time, &song.Link)
 
 	if err != nil {
 		x.Log().Error("can't query", "error", err, "query", q, "song", song)
-		return zero, ErrInternalError
+		return zero, internalError(err)
 	}
 
 	return song, nil
 }
 
 // GetSong возвращает детальную информацию о песне по ID. Есле в базе нет такого ID возвращает ErrNotFound.
+// Вне транзакции песня берется из кэша, если она там есть.
 func (r LocalRepo) GetSong(ctx context.Context, songID uint64) (SongDetail, error) {
 	x := newHelper(ctx, "GetSong")
 	var zero SongDetail
 
+	// в транзакции кэш не используется: там могут быть незафиксированные изменения
+	inTx := getTxState(ctx) != nil
+
+	if !inTx {
+		if song, ok := r.songs.Get(songID); ok {
+			return song, nil
+		}
+	}
+
 	const q = `
 		SELECT s.id, s.name, g.name, s.release, s.text, s.link
 		FROM song AS s JOIN "group" AS g ON s.group_id = g.id
@@ -84,7 +98,7 @@ func (r LocalRepo) GetSong(ctx context.Context, songID uint64) (SongDetail, erro
 
 	var song SongDetail
 
-	err := r.db.QueryRowContext(ctx, q, songID).
+	err := r.querier(ctx).QueryRowContext(ctx, q, songID).
 		Scan(&song.ID, &song.Name, &song.Group, &song.Release.Time, &song.Text, &song.Link)
 
 	if err != nil {
@@ -94,7 +108,11 @@ func (r LocalRepo) GetSong(ctx context.Context, songID uint64) (SongDetail, erro
 		}
 
 		x.Log().Error("can't query", "error", err, "query", q, "songID", songID)
-		return zero, ErrInternalError
+		return zero, internalError(err)
+	}
+
+	if !inTx {
+		r.songs.Set(songID, song)
 	}
 
 	return song, nil
@@ -106,8 +124,46 @@ func (r LocalRepo) ListSongs(ctx context.Context, req SongListFilters) ([]SongDe
 	x := newHelper(ctx, "ListSongs")
 	var zero []SongDetail
 
+	q, values := listSongsQuery(req, false)
+
+	rows, err := r.querier(ctx).QueryContext(ctx, q, values...)
+	if err != nil {
+
+		x.Log().Error("can't query", "error", err, "query", q, "req", req)
+		return zero, internalError(err)
+	}
+
+	defer rows.Close()
+
+	var (
+		song SongDetail
+		resp []SongDetail
+	)
+
+	for rows.Next() {
+		if err := rows.Scan(&song.ID, &song.Name, &song.Group, &song.Release.Time, &song.Link, &song.Text); err != nil {
+
+			x.Log().Error("can't scan", "error", err, "query", q)
+			return zero, internalError(err)
+		}
+
+		resp = append(resp, song)
+	}
+
+	if err := rows.Err(); err != nil {
+		x.Log().Error("can't get next row", "error", err)
+		return zero, internalError(err)
+	}
+
+	return resp, nil
+}
+
+// listSongsQuery строит запрос списка песен по фильтрам. Если withText == false, то вместо
+// текста песни выбирается пустая строка.
+func listSongsQuery(req SongListFilters, withText bool) (string, []any) {
+
 	var q = `
-		SELECT s.id, s.name, g.name, s.release, link
+		SELECT s.id, s.name, g.name, s.release, s.link, %s /* text placeholder */
 		FROM song AS s JOIN "group" AS g ON s.group_id = g.id
 		%s /* where placeholder */
 		ORDER BY s.id
@@ -145,8 +201,19 @@ func (r LocalRepo) ListSongs(ctx context.Context, req SongListFilters) ([]SongDe
 		filters = append(filters, fmt.Sprintf(`s.link = $%d`, idx))
 		values = append(values, req.Link)
 	}
+	if req.Lang != nil {
+		idx++
+		filters = append(filters, fmt.Sprintf(`s.id IN (SELECT song_id FROM song_stats WHERE lang = $%d)`, idx))
+		values = append(values, req.Lang)
+	}
 
 	q = fmt.Sprintf(q,
+		func() string {
+			if withText {
+				return "s.text"
+			}
+			return "''"
+		}(),
 		func() string {
 			if len(filters) != 0 {
 				return "WHERE " + strings.Join(filters, " AND ")
@@ -171,36 +238,7 @@ func (r LocalRepo) ListSongs(ctx context.Context, req SongListFilters) ([]SongDe
 		}(),
 	)
 
-	rows, err := r.db.QueryContext(ctx, q, values...)
-	if err != nil {
-
-		x.Log().Error("can't query", "error", err, "query", q, "req", req)
-		return zero, ErrInternalError
-	}
-
-	defer rows.Close()
-
-	var (
-		song SongDetail
-		resp []SongDetail
-	)
-
-	for rows.Next() {
-		if err := rows.Scan(&song.ID, &song.Name, &song.Group, &song.Release.Time, &song.Link); err != nil {
-
-			x.Log().Error("can't scan", "error", err, "query", q)
-			return zero, ErrInternalError
-		}
-
-		resp = append(resp, song)
-	}
-
-	if err := rows.Err(); err != nil {
-		x.Log().Error("can't get next row", "error", err)
-		return zero, ErrInternalError
-	}
-
-	return resp, nil
+	return q, values
 }
 
 type UpdateSongRequest = model.SongUpdate
@@ -208,6 +246,21 @@ type UpdateSongRequest = model.SongUpdate
 // UpdateSong обновляет информацию о песне с указанным ID. Возвращает детальную информацию о песне.
 // Если песня с указанным ID отсутствует в базе, то возвращает ErrNotFound.
 func (r LocalRepo) UpdateSong(ctx context.Context, req UpdateSongRequest) (SongDetail, error) {
+	var song SongDetail
+
+	// песня и ее оригинальная языковая версия обновляются вместе
+	err := r.WithTx(ctx, TxOptions{}, func(ctx context.Context) error {
+		var err error
+		song, err = r.updateSong(ctx, req)
+		return err
+	})
+
+	r.invalidateSong(ctx, req.ID)
+
+	return song, err
+}
+
+func (r LocalRepo) updateSong(ctx context.Context, req UpdateSongRequest) (SongDetail, error) {
 	x := newHelper(ctx, "UpdateSong")
 
 	// ВАЖНО: В RETURNING нужно указывать s1. Если указать s2, будут возвращены СТАРЫЕ (очевидно на момент JOIN?) значения.
@@ -273,7 +326,7 @@ func (r LocalRepo) UpdateSong(ctx context.Context, req UpdateSongRequest) (SongD
 
 	var song SongDetail
 
-	err := r.db.QueryRowContext(ctx, q, values...).
+	err := r.querier(ctx).QueryRowContext(ctx, q, values...).
 		Scan(&song.ID, &song.Name, &song.Group, &song.Release.Time, &song.Text, &song.Link)
 
 	if err != nil {
@@ -283,7 +336,19 @@ func (r LocalRepo) UpdateSong(ctx context.Context, req UpdateSongRequest) (SongD
 		}
 
 		x.Log().Error("can't query", "error", err, "query", q, "values", values, "req", req)
-		return zero, ErrInternalError
+		return zero, internalError(err)
+	}
+
+	if req.Text != nil {
+
+		// текст песни и оригинальная языковая версия должны совпадать
+		const q = `UPDATE song_lyrics SET text = $2 WHERE song_id = $1 AND original`
+
+		if _, err := r.querier(ctx).ExecContext(ctx, q, song.ID, song.Text); err != nil {
+
+			x.Log().Error("can't query", "error", err, "query", q, "songID", song.ID)
+			return zero, internalError(err)
+		}
 	}
 
 	return song, nil
@@ -297,11 +362,32 @@ func (r LocalRepo) DeleteSong(ctx context.Context, songID uint64) error {
 
 	const q = `DELETE FROM song WHERE id = $1`
 
-	if _, err := r.db.ExecContext(ctx, q, songID); err != nil {
+	if _, err := r.querier(ctx).ExecContext(ctx, q, songID); err != nil {
 
 		x.Log().Error("can't query", "error", err, "query", q, "songID", songID)
-		return ErrInternalError
+		return internalError(err)
 	}
 
+	r.invalidateSong(ctx, songID)
+
 	return nil
 }
+
+// invalidateSong удаляет песню из кэша. В транзакции песня будет удалена еще раз после
+// фиксации: до нее параллельный читатель может снова закэшировать старую версию.
+func (r LocalRepo) invalidateSong(ctx context.Context, songID uint64) {
+	r.songs.Delete(songID)
+	if state := getTxState(ctx); state != nil {
+		state.invalidated = append(state.invalidated, songID)
+	}
+}
+
+// CacheStats возвращает статистику кэша песен.
+func (r LocalRepo) CacheStats() cache.Stats {
+	return r.songs.Stats()
+}
+
+// SetCacheTTL меняет TTL песен, кэшируемых после вызова. Размер кэша не меняется.
+func (r LocalRepo) SetCacheTTL(cfg config.Cache) {
+	r.songs.SetTTL(cfg.SongTTL)
+}
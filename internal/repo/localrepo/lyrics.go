@@ -0,0 +1,119 @@
+package localrepo
+
+import (
+	"context"
+	"database/sql"
+
+	"effective-mobile-go/internal/model"
+)
+
+type SongLyrics = model.SongLyrics
+
+// ListSongLyrics возвращает все языковые версии текста песни. Если версий нет, то возвращает
+// пустой список (наличие самой песни НЕ проверяется).
+func (r LocalRepo) ListSongLyrics(ctx context.Context, songID uint64) ([]SongLyrics, error) {
+	x := newHelper(ctx, "ListSongLyrics")
+
+	const q = `
+		SELECT song_id, lang, original, translator, text
+		FROM song_lyrics
+		WHERE song_id = $1
+		ORDER BY original DESC, lang
+	`
+
+	rows, err := r.querier(ctx).QueryContext(ctx, q, songID)
+	if err != nil {
+
+		x.Log().Error("can't query", "error", err, "query", q, "songID", songID)
+		return nil, internalError(err)
+	}
+
+	defer rows.Close()
+
+	var (
+		lyrics SongLyrics
+		resp   []SongLyrics
+	)
+
+	for rows.Next() {
+		if err := rows.Scan(&lyrics.SongID, &lyrics.Lang, &lyrics.Original, &lyrics.Translator, &lyrics.Text); err != nil {
+
+			x.Log().Error("can't scan", "error", err, "query", q)
+			return nil, internalError(err)
+		}
+
+		resp = append(resp, lyrics)
+	}
+
+	if err := rows.Err(); err != nil {
+		x.Log().Error("can't get next row", "error", err)
+		return nil, internalError(err)
+	}
+
+	return resp, nil
+}
+
+// PutSongLyrics создает или заменяет версию текста песни на указанном языке. Если версия
+// помечена как оригинал, то она заменяет текст самой песни, а с остальных версий флаг
+// оригинала снимается. Если песни с указанным ID нет в базе, то возвращает ErrNotFound.
+func (r LocalRepo) PutSongLyrics(ctx context.Context, lyrics SongLyrics) (SongLyrics, error) {
+	x := newHelper(ctx, "PutSongLyrics")
+	var zero SongLyrics
+
+	// ВАЖНО: ограничение "один оригинал на песню" отложенное (DEFERRABLE), иначе смена
+	// оригинала одним запросом невозможна - порядок выполнения CTE не определен.
+	const q = `
+		WITH
+		sel_song AS (
+			SELECT id FROM song WHERE id = $1
+		)
+		,upd_others AS (
+			UPDATE song_lyrics SET original = FALSE
+			WHERE $3 AND song_id = $1 AND lang <> $2 AND original
+		)
+		,upd_song AS (
+			UPDATE song SET text = $5
+			WHERE $3 AND id = $1
+		)
+		INSERT INTO song_lyrics (song_id, lang, original, translator, text)
+		SELECT id, $2, $3, $4, $5 FROM sel_song
+		ON CONFLICT (song_id, lang) DO UPDATE
+		SET original = EXCLUDED.original, translator = EXCLUDED.translator, text = EXCLUDED.text
+		RETURNING song_id, lang, original, translator, text
+	`
+
+	err := r.querier(ctx).QueryRowContext(ctx, q, lyrics.SongID, lyrics.Lang, lyrics.Original, lyrics.Translator, lyrics.Text).
+		Scan(&lyrics.SongID, &lyrics.Lang, &lyrics.Original, &lyrics.Translator, &lyrics.Text)
+
+	if err != nil {
+
+		if err == sql.ErrNoRows {
+			return zero, ErrNotFound
+		}
+
+		x.Log().Error("can't query", "error", err, "query", q, "songID", lyrics.SongID, "lang", lyrics.Lang)
+		return zero, internalError(err)
+	}
+
+	if lyrics.Original {
+		r.invalidateSong(ctx, lyrics.SongID) // the song text has been replaced
+	}
+
+	return lyrics, nil
+}
+
+// DeleteSongLyrics удаляет версию текста песни на указанном языке. Как и DeleteSong, НЕ
+// возвращает ошибку, если такой версии нет. Текст самой песни при этом не меняется.
+func (r LocalRepo) DeleteSongLyrics(ctx context.Context, songID uint64, lang string) error {
+	x := newHelper(ctx, "DeleteSongLyrics")
+
+	const q = `DELETE FROM song_lyrics WHERE song_id = $1 AND lang = $2`
+
+	if _, err := r.querier(ctx).ExecContext(ctx, q, songID, lang); err != nil {
+
+		x.Log().Error("can't query", "error", err, "query", q, "songID", songID, "lang", lang)
+		return internalError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package localrepo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// TakeRateLimit берет токен из корзины ключа по алгоритму GCRA: TAT (время, когда корзина
+// снова полна) сдвигается на interval, если после этого до него не больше period. Проверка
+// и изменение выполняются одним запросом, поэтому атомарны. Возвращает TAT после попытки
+// и время базы.
+func (r LocalRepo) TakeRateLimit(ctx context.Context, key string, interval, period time.Duration) (time.Time, time.Time, bool, error) {
+	x := newHelper(ctx, "TakeRateLimit")
+	var zero time.Time
+
+	const q = `
+		INSERT INTO rate_limit AS rl (key, tat) VALUES ($1, now() + $2 * interval '1 microsecond')
+		ON CONFLICT (key) DO UPDATE
+		SET tat = greatest(rl.tat, now()) + $2 * interval '1 microsecond'
+		WHERE greatest(rl.tat, now()) + $2 * interval '1 microsecond' <= now() + $3 * interval '1 microsecond'
+		RETURNING tat, now()
+	`
+
+	var tat, now time.Time
+
+	err := r.querier(ctx).QueryRowContext(ctx, q, key, interval.Microseconds(), period.Microseconds()).
+		Scan(&tat, &now)
+
+	if err == nil {
+		return tat, now, true, nil
+	}
+
+	if err != sql.ErrNoRows {
+
+		x.Log().Error("can't query", "error", err, "query", q, "key", key)
+		return zero, zero, false, internalError(err)
+	}
+
+	// корзина пуста, TAT нужен только для Retry-After
+	const q2 = `SELECT tat, now() FROM rate_limit WHERE key = $1`
+
+	if err := r.querier(ctx).QueryRowContext(ctx, q2, key).Scan(&tat, &now); err != nil {
+
+		x.Log().Error("can't query", "error", err, "query", q2, "key", key)
+		return zero, zero, false, internalError(err)
+	}
+
+	return tat, now, false, nil
+}
+
+// DeleteFullRateLimits удаляет полные корзины: они не отличаются от отсутствующих.
+func (r LocalRepo) DeleteFullRateLimits(ctx context.Context) error {
+	x := newHelper(ctx, "DeleteFullRateLimits")
+
+	const q = `DELETE FROM rate_limit WHERE tat <= now()`
+
+	if _, err := r.querier(ctx).ExecContext(ctx, q); err != nil {
+
+		x.Log().Error("can't query", "error", err, "query", q)
+		return internalError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package localrepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"effective-mobile-go/internal/model"
+)
+
+type SongStats = model.SongStats
+
+// PutSongStats создает или заменяет статистику текста песни. Если песни с указанным ID
+// нет в базе, то возвращает ErrNotFound.
+func (r LocalRepo) PutSongStats(ctx context.Context, stats SongStats) error {
+	x := newHelper(ctx, "PutSongStats")
+
+	const q = `
+		INSERT INTO song_stats (song_id, verses, lines, words, unique_words, unique_ratio, lang, reading_time, word_freq)
+		SELECT id, $2, $3, $4, $5, $6, $7, $8, $9::jsonb FROM song WHERE id = $1
+		ON CONFLICT (song_id) DO UPDATE
+		SET verses = EXCLUDED.verses, lines = EXCLUDED.lines, words = EXCLUDED.words,
+			unique_words = EXCLUDED.unique_words, unique_ratio = EXCLUDED.unique_ratio,
+			lang = EXCLUDED.lang, reading_time = EXCLUDED.reading_time,
+			word_freq = EXCLUDED.word_freq, updated_at = now()
+	`
+
+	wordFreq, err := json.Marshal(stats.WordFreq)
+	if err != nil {
+
+		x.Log().Error("can't marshal word frequencies", "error", err)
+		return internalError(err)
+	}
+
+	res, err := r.querier(ctx).ExecContext(ctx, q, stats.SongID, stats.Verses, stats.Lines, stats.Words, stats.UniqueWords,
+		stats.UniqueRatio, stats.Lang, int64(stats.ReadingTime/time.Second), string(wordFreq))
+
+	if err != nil {
+
+		x.Log().Error("can't query", "error", err, "query", q, "songID", stats.SongID)
+		return internalError(err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetSongStats возвращает статистику текста песни. Если статистики нет (в том числе, если
+// нет самой песни), то возвращает ErrNotFound.
+func (r LocalRepo) GetSongStats(ctx context.Context, songID uint64) (SongStats, error) {
+	x := newHelper(ctx, "GetSongStats")
+	var zero SongStats
+
+	const q = `
+		SELECT song_id, verses, lines, words, unique_words, unique_ratio, lang, reading_time, word_freq
+		FROM song_stats
+		WHERE song_id = $1
+	`
+
+	var (
+		stats       SongStats
+		readingTime int64
+		wordFreq    []byte
+	)
+
+	err := r.querier(ctx).QueryRowContext(ctx, q, songID).
+		Scan(&stats.SongID, &stats.Verses, &stats.Lines, &stats.Words, &stats.UniqueWords,
+			&stats.UniqueRatio, &stats.Lang, &readingTime, &wordFreq)
+
+	if err != nil {
+
+		if err == sql.ErrNoRows {
+			return zero, ErrNotFound
+		}
+
+		x.Log().Error("can't query", "error", err, "query", q, "songID", songID)
+		return zero, internalError(err)
+	}
+
+	if err := json.Unmarshal(wordFreq, &stats.WordFreq); err != nil {
+
+		x.Log().Error("can't unmarshal word frequencies", "error", err, "songID", songID)
+		return zero, internalError(err)
+	}
+
+	stats.ReadingTime = time.Duration(readingTime) * time.Second
+
+	return stats, nil
+}
@@ -0,0 +1,60 @@
+package localrepo
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"effective-mobile-go/internal/tracing"
+)
+
+// tracedQuerier оборачивает каждый запрос в span. Span запроса QueryContext заканчивается,
+// когда запрос выполнен, чтение строк в него не входит.
+type tracedQuerier struct {
+	q querier
+}
+
+func (t tracedQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := startQuery(ctx, query)
+	res, err := t.q.ExecContext(ctx, query, args...)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (t tracedQuerier) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := startQuery(ctx, query)
+	rows, err := t.q.QueryContext(ctx, query, args...)
+	tracing.End(span, err)
+	return rows, err
+}
+
+func (t tracedQuerier) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := startQuery(ctx, query)
+	row := t.q.QueryRowContext(ctx, query, args...)
+
+	err := row.Err()
+	if err == sql.ErrNoRows {
+		err = nil // не ошибка запроса
+	}
+	tracing.End(span, err)
+
+	return row
+}
+
+func startQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+
+	query = strings.TrimSpace(query)
+
+	operation, _, _ := strings.Cut(query, " ")
+	operation = strings.ToUpper(strings.TrimSpace(operation))
+
+	return tracing.Start(ctx, "postgresql "+operation,
+		semconv.DBSystemPostgreSQL,
+		semconv.DBOperationName(operation),
+		attribute.String("db.query.text", query),
+	)
+}
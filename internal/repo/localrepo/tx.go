@@ -0,0 +1,164 @@
+package localrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+
+	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/tracing"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// txState is stored in the context of the unit of work.
+type txState struct {
+	tx          *sql.Tx
+	invalidated []uint64 // songs to drop from the cache once more after commit
+}
+
+type TxOptions = model.TxOptions
+
+const (
+	retryBaseDelay = 10 * time.Millisecond
+	retryMaxDelay  = time.Second
+)
+
+// querier возвращает транзакцию из контекста, если она там есть, иначе базу.
+func (r LocalRepo) querier(ctx context.Context) querier {
+	if state := getTxState(ctx); state != nil {
+		return tracedQuerier{state.tx}
+	}
+	return tracedQuerier{r.db}
+}
+
+func getTxState(ctx context.Context) *txState {
+	state, _ := ctx.Value(txKey{}).(*txState)
+	return state
+}
+
+// WithTx выполняет fn как единицу работы (unit of work): все вызовы методов LocalRepo с
+// контекстом, переданным в fn, выполняются в одной транзакции. Если fn возвращает ошибку, то
+// транзакция откатывается, иначе фиксируется.
+//
+// Если транзакция не удалась из-за конфликта сериализации или взаимоблокировки, то она
+// повторяется (с паузой) до opts.Retries раз. Поэтому fn может быть вызвана несколько раз
+// и не должна иметь побочных эффектов вне базы (или они должны быть идемпотентны).
+//
+// Если контекст уже содержит транзакцию, то fn выполняется в ней, а opts игнорируются
+// (вложенных транзакций нет, повтор - забота внешней единицы работы).
+func (r LocalRepo) WithTx(ctx context.Context, opts TxOptions, fn func(ctx context.Context) error) (err error) {
+	x := newHelper(ctx, "WithTx")
+
+	if getTxState(ctx) != nil {
+		return fn(ctx)
+	}
+
+	ctx, span := tracing.Start(ctx, "LocalRepo.WithTx")
+	defer func() { tracing.End(span, err) }()
+
+	for attempt := 0; ; attempt++ {
+
+		span.SetAttributes(attribute.Int("db.tx.attempts", attempt+1))
+
+		err := r.runTx(ctx, opts, fn)
+		if err == nil || !isRetryable(err) || attempt >= opts.Retries {
+			return err
+		}
+
+		delay := min(retryBaseDelay<<attempt, retryMaxDelay)
+		delay = delay/2 + rand.N(delay/2) // jitter
+
+		x.Log().Debug("transaction conflict, retry", "attempt", attempt+1, "delay", delay, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (r LocalRepo) runTx(ctx context.Context, opts TxOptions, fn func(ctx context.Context) error) error {
+	x := newHelper(ctx, "WithTx")
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: opts.Isolation,
+		ReadOnly:  opts.ReadOnly,
+	})
+
+	if err != nil {
+
+		x.Log().Error("can't begin transaction", "error", err)
+		return internalError(err)
+	}
+
+	state := &txState{tx: tx}
+
+	if err := fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+
+		if err := tx.Rollback(); err != nil {
+			x.Log().Error("can't rollback transaction", "error", err)
+		}
+
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+
+		if isRetryable(err) {
+			x.Log().Debug("can't commit transaction", "error", err)
+		} else {
+			x.Log().Error("can't commit transaction", "error", err)
+		}
+
+		return internalError(err)
+	}
+
+	// a concurrent reader could cache the old version between our update and commit
+	for _, songID := range state.invalidated {
+		r.songs.Delete(songID)
+	}
+
+	return nil
+}
+
+// isRetryable сообщает, что ошибка вызвана конфликтом сериализации (40001) или
+// взаимоблокировкой (40P01), т.е. транзакцию можно повторить.
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == "40001" || pgErr.Code == "40P01")
+}
+
+// LockSong берет транзакционную advisory-блокировку песни (по группе и названию без учета
+// регистра), т.е. ждет, пока ее не отпустит другая транзакция, в том числе другого экземпляра
+// сервиса. Блокировка отпускается в конце транзакции, поэтому имеет смысл только внутри WithTx.
+func (r LocalRepo) LockSong(ctx context.Context, group, name string) error {
+	x := newHelper(ctx, "LockSong")
+
+	const q = `SELECT pg_advisory_xact_lock(hashtextextended(lower(trim($1)) || E'\n' || lower(trim($2)), 0))`
+
+	if getTxState(ctx) == nil {
+		x.Log().Warn("lock outside of transaction is useless", "group", group, "name", name)
+	}
+
+	if _, err := r.querier(ctx).ExecContext(ctx, q, group, name); err != nil {
+
+		x.Log().Error("can't query", "error", err, "query", q, "group", group, "name", name)
+		return internalError(err)
+	}
+
+	return nil
+}
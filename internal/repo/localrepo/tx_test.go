@@ -0,0 +1,141 @@
+package localrepo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"effective-mobile-go/internal/config"
+)
+
+func Test_isRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"other", errors.New("other"), false},
+		{"unique violation", internalError(&pgconn.PgError{Code: "23505"}), false},
+		{"serialization failure", internalError(&pgconn.PgError{Code: "40001"}), true},
+		{"deadlock", internalError(&pgconn.PgError{Code: "40P01"}), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable() = %v, want %v", got, tt.want)
+			}
+			if tt.err != nil && tt.name != "other" && !errors.Is(tt.err, ErrInternalError) {
+				t.Errorf("errors.Is(err, ErrInternalError) = false")
+			}
+		})
+	}
+}
+
+// fakeDriver is the database/sql driver which only begins, commits and rolls back the
+// transactions. commitErrs are returned by the next commits.
+type fakeDriver struct {
+	begins, commits, rollbacks int
+	commitErrs                 []error
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not implemented") }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.d.begins++
+	return fakeTx{c.d}, nil
+}
+
+type fakeTx struct{ d *fakeDriver }
+
+func (tx fakeTx) Commit() error {
+	tx.d.commits++
+	if len(tx.d.commitErrs) > 0 {
+		err := tx.d.commitErrs[0]
+		tx.d.commitErrs = tx.d.commitErrs[1:]
+		return err
+	}
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.d.rollbacks++
+	return nil
+}
+
+func (d *fakeDriver) OpenConnector(string) (driver.Connector, error) { return d, nil }
+func (d *fakeDriver) Connect(context.Context) (driver.Conn, error)   { return fakeConn{d}, nil }
+func (d *fakeDriver) Driver() driver.Driver                          { return d }
+
+func TestLocalRepo_WithTx_retry(t *testing.T) {
+
+	conflict := &pgconn.PgError{Code: "40001"}
+	deadlock := &pgconn.PgError{Code: "40P01"}
+	other := errors.New("other")
+
+	tests := []struct {
+		name         string
+		fnErrs       []error // returned by the calls of fn, nil after them
+		commitErrs   []error
+		retries      int
+		wantErr      error
+		wantAttempts int
+	}{
+		{"ok", nil, nil, 3, nil, 1},
+		{"conflict then ok", []error{conflict, deadlock}, nil, 3, nil, 3},
+		{"commit conflict then ok", nil, []error{conflict}, 3, nil, 2},
+		{"retries exceeded", []error{conflict, conflict, conflict}, nil, 2, conflict, 3},
+		{"no retries", []error{deadlock}, nil, 0, deadlock, 1},
+		{"other error", []error{other, conflict}, nil, 3, other, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			d := &fakeDriver{commitErrs: tt.commitErrs}
+			db := sql.OpenDB(d)
+			defer db.Close()
+
+			r := New(db, config.Cache{})
+
+			attempts := 0
+			err := r.WithTx(context.Background(), TxOptions{Isolation: sql.LevelSerializable, Retries: tt.retries}, func(ctx context.Context) error {
+
+				if getTxState(ctx) == nil {
+					t.Error("no transaction in the context")
+				}
+
+				attempts++
+				if attempts <= len(tt.fnErrs) {
+					return tt.fnErrs[attempts-1]
+				}
+				return nil
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			if attempts != tt.wantAttempts || d.begins != tt.wantAttempts {
+				t.Errorf("attempts = %d, begins = %d, want %d", attempts, d.begins, tt.wantAttempts)
+			}
+
+			if d.commits+d.rollbacks != d.begins {
+				t.Errorf("commits = %d, rollbacks = %d, begins = %d: not every transaction is finished", d.commits, d.rollbacks, d.begins)
+			}
+		})
+	}
+}
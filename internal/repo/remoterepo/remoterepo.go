@@ -8,12 +8,19 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+
 	"effective-mobile-go/internal/config"
 	"effective-mobile-go/internal/lib"
 	"effective-mobile-go/internal/logger"
+	"effective-mobile-go/internal/metrics"
 	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/reqinfo"
+	"effective-mobile-go/internal/tracing"
 )
 
 type SongDetail = model.SongDetail
@@ -26,30 +33,67 @@ var (
 
 const loggerGroup = "remoterepo"
 
+// RemoteRepo is the client of the music info API. The copies share the config, which
+// can be changed at runtime with SetConfig.
 type RemoteRepo struct {
-	url string
+	cfg *atomic.Pointer[config.RemoteAPI]
 }
 
 func New(cfg config.RemoteAPI) RemoteRepo {
-	return RemoteRepo{
-		url: cfg.URL,
-	}
+	r := RemoteRepo{cfg: new(atomic.Pointer[config.RemoteAPI])}
+	r.SetConfig(cfg)
+	return r
+}
+
+// SetConfig changes the URL and the timeout of the requests started after the call.
+func (r RemoteRepo) SetConfig(cfg config.RemoteAPI) {
+	r.cfg.Store(&cfg)
+}
+
+func (r RemoteRepo) GetSong(ctx context.Context, song SongDetail) (_ SongDetail, err error) {
+	ctx, span := tracing.Start(ctx, "RemoteRepo.GetSong")
+	defer func() { tracing.End(span, err) }()
+
+	start := time.Now()
+	song, outcome, err := r.getSong(ctx, song)
+	metrics.ObserveRemoteRequest(outcome, time.Since(start))
+
+	span.SetAttributes(attribute.String("remote.outcome", outcome))
+
+	return song, err
 }
 
-func (r RemoteRepo) GetSong(ctx context.Context, song SongDetail) (SongDetail, error) {
+func (r RemoteRepo) getSong(ctx context.Context, song SongDetail) (SongDetail, string, error) {
 	const op = "GetSong"
 	var zero SongDetail
 
-	client := http.Client{Timeout: 10 * time.Second} // TODO: timeout to config
+	cfg := r.cfg.Load()
+
+	client := http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: otelhttp.NewTransport(http.DefaultTransport),
+	}
 
-	url := fmt.Sprintf("%s?group=%s&song=%s", r.url, url.QueryEscape(song.Group),
+	url := fmt.Sprintf("%s?group=%s&song=%s", cfg.URL, url.QueryEscape(song.Group),
 		url.QueryEscape(song.Name))
 
-	resp, err := client.Get(url)
+	// the transport starts the client span and sends traceparent header
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+
+		log(ctx).Error("can't create request", "error", err, "url", url)
+		return zero, metrics.RemoteTransport, ErrInternalError
+	}
+
+	if id := reqinfo.FromContext(ctx).ID; id != "" {
+		req.Header.Set(reqinfo.Header, id)
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
 
 		log(ctx).Error("can't get ulr", "error", err, "url", url)
-		return zero, ErrInternalError
+		return zero, metrics.RemoteTransport, ErrInternalError
 	}
 
 	defer resp.Body.Close()
@@ -58,44 +102,71 @@ func (r RemoteRepo) GetSong(ctx context.Context, song SongDetail) (SongDetail, e
 
 		var (
 			writeLog func(msg string, args ...any)
+			outcome  string
 			err      error
 		)
 
 		switch resp.StatusCode {
 		case http.StatusBadRequest:
 			writeLog = log(ctx).Debug
-			err = ErrBadRequest
+			outcome, err = metrics.RemoteBadRequest, ErrBadRequest
 		case http.StatusNotFound:
 			writeLog = log(ctx).Debug
-			err = ErrNotFound
+			outcome, err = metrics.RemoteNotFound, ErrNotFound
 		default:
 			writeLog = log(ctx).Error
-			err = ErrInternalError
+			outcome, err = metrics.RemoteBadStatus, ErrInternalError
 		}
 
 		body, _ := io.ReadAll(resp.Body)
 		writeLog("remote server returned not OK status", "op", op, "url", url,
 			"statusCode", resp.StatusCode, "body", lib.UnsafeString(body))
-		return zero, err
+		return zero, outcome, err
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 
 		log(ctx).Error("can't read response body", "op", op, "error", err, "url", url)
-		return zero, ErrInternalError
+		return zero, metrics.RemoteTransport, ErrInternalError
 	}
 
 	if err := json.Unmarshal(body, &song); err != nil {
 
 		log(ctx).Error("can't parse response body", "op", op, "error", err, "url", url,
 			"body", lib.UnsafeString(body))
-		return zero, ErrInternalError
+		return zero, metrics.RemoteBadResponse, ErrInternalError
 	}
 
 	song.ID = 0 // for security
 
-	return song, nil
+	return song, metrics.RemoteOK, nil
+}
+
+// Ping checks that the remote API answers. Any status but 5xx means it's up: the request
+// has no song, so the API may reject it.
+func (r RemoteRepo) Ping(ctx context.Context) error {
+
+	client := http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)} // ctx has the timeout
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.cfg.Load().URL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("remote API status: %s", resp.Status)
+	}
+
+	return nil
 }
 
 func log(ctx context.Context) *slog.Logger {
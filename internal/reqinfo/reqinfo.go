@@ -0,0 +1,56 @@
+// Package reqinfo carries the request details which are learned by the inner handlers
+// (the route pattern after routing, the principal after authentication) out to the
+// outer middleware, which logs and measures the request.
+package reqinfo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const Header = "X-Request-ID"
+
+const maxIDLength = 128
+
+type Info struct {
+	ID        string
+	Route     string // the route pattern, empty if not matched
+	Principal string
+}
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, info *Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// FromContext returns the info of the request. It is never nil: if the context has none,
+// the changes are just lost.
+func FromContext(ctx context.Context) *Info {
+	if info, ok := ctx.Value(contextKey{}).(*Info); ok {
+		return info
+	}
+	return &Info{}
+}
+
+// NewID returns a new random request ID.
+func NewID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// IsValidID reports whether the incoming request ID may be used: it goes to logs and
+// headers as is, so only visible ASCII is allowed.
+func IsValidID(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
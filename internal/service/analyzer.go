@@ -0,0 +1,119 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"effective-mobile-go/internal/model"
+)
+
+const (
+	readingSpeed = 200 // words per minute
+	wordFreqTop  = 50  // how many most frequent words to keep
+)
+
+// stopWords are the most frequent words of the languages recognized by detectLang.
+var stopWords = map[string][]string{
+	"en": {"the", "and", "you", "to", "of", "it", "in", "is", "that", "my", "me", "i", "your", "don't", "can", "be", "on", "we", "for", "with"},
+	"de": {"der", "die", "und", "das", "ich", "du", "nicht", "ist", "ein", "zu", "mich", "mir", "sie", "es", "wir", "mit", "auf", "dich", "den", "dem"},
+	"fr": {"le", "la", "les", "et", "je", "tu", "de", "un", "une", "pas", "est", "que", "qui", "dans", "mon", "moi", "toi", "nous", "vous", "pour"},
+	"es": {"el", "la", "los", "las", "y", "que", "de", "yo", "tu", "no", "es", "en", "un", "una", "mi", "me", "te", "por", "con", "para"},
+	"it": {"il", "la", "che", "di", "e", "non", "un", "una", "io", "tu", "mi", "ti", "per", "con", "sono", "sei", "del", "della", "lo", "gli"},
+	"pt": {"o", "a", "os", "as", "e", "que", "de", "eu", "não", "um", "uma", "em", "meu", "minha", "você", "com", "para", "se", "do", "da"},
+	"ru": {"и", "в", "не", "я", "ты", "на", "что", "с", "мне", "меня", "как", "а", "но", "это", "мы", "так", "все", "по", "он", "она"},
+	"uk": {"і", "й", "в", "не", "я", "ти", "на", "що", "з", "мені", "мене", "як", "а", "але", "це", "ми", "так", "все", "він", "вона"},
+}
+
+// analyzeText computes statistics of the song text. The word frequencies are limited
+// by wordFreqTop most frequent words.
+func analyzeText(text string) model.SongStats {
+	var stats model.SongStats
+
+	for _, verse := range splitVerses(text) {
+		if strings.TrimSpace(verse) != "" {
+			stats.Verses++
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) != "" {
+			stats.Lines++
+		}
+	}
+
+	words := splitWords(text)
+	freq := map[string]int{}
+	for _, word := range words {
+		freq[word]++
+	}
+
+	stats.Words = len(words)
+	stats.UniqueWords = len(freq)
+	if stats.Words > 0 {
+		stats.UniqueRatio = math.Round(float64(stats.UniqueWords)/float64(stats.Words)*1000) / 1000
+	}
+
+	stats.Lang = detectLang(freq)
+	stats.ReadingTime = time.Duration(math.Ceil(float64(stats.Words)*60/readingSpeed)) * time.Second
+
+	stats.WordFreq = make([]model.WordCount, 0, len(freq))
+	for word, count := range freq {
+		stats.WordFreq = append(stats.WordFreq, model.WordCount{Word: word, Count: count})
+	}
+
+	sort.Slice(stats.WordFreq, func(i, j int) bool {
+		a, b := stats.WordFreq[i], stats.WordFreq[j]
+		return a.Count > b.Count || a.Count == b.Count && a.Word < b.Word
+	})
+
+	if len(stats.WordFreq) > wordFreqTop {
+		stats.WordFreq = stats.WordFreq[:wordFreqTop]
+	}
+
+	return stats
+}
+
+// splitWords returns lower-cased words of the text. The apostrophe inside a word
+// is a part of it (don't, rock'n'roll).
+func splitWords(text string) []string {
+
+	var words []string
+
+	for _, field := range strings.FieldsFunc(text, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '\'' && c != '’'
+	}) {
+
+		word := strings.Trim(strings.ReplaceAll(field, "’", "'"), "'")
+		if word != "" {
+			words = append(words, strings.ToLower(word))
+		}
+	}
+
+	return words
+}
+
+// detectLang guesses the language by the stop words. Returns "" if it can't.
+func detectLang(freq map[string]int) string {
+
+	var (
+		lang string
+		best int
+	)
+
+	for l, list := range stopWords {
+
+		score := 0
+		for _, word := range list {
+			score += freq[word]
+		}
+
+		if score > best || score == best && score > 0 && l < lang {
+			lang, best = l, score
+		}
+	}
+
+	return lang
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"effective-mobile-go/internal/model"
+)
+
+func Test_analyzeText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want model.SongStats
+	}{
+		{
+			"empty",
+			"",
+			model.SongStats{WordFreq: []model.WordCount{}},
+		},
+		{
+			"english",
+			"You set my soul alight\nYou set my soul\n\nOoh, don't you know?",
+			model.SongStats{
+				Verses:      2,
+				Lines:       3,
+				Words:       13,
+				UniqueWords: 8,
+				UniqueRatio: 0.615,
+				Lang:        "en",
+				ReadingTime: 4 * time.Second,
+				WordFreq: []model.WordCount{
+					{Word: "you", Count: 3},
+					{Word: "my", Count: 2},
+					{Word: "set", Count: 2},
+					{Word: "soul", Count: 2},
+					{Word: "alight", Count: 1},
+					{Word: "don't", Count: 1},
+					{Word: "know", Count: 1},
+					{Word: "ooh", Count: 1},
+				},
+			},
+		},
+		{
+			"russian",
+			"Я не я, и лошадь не моя",
+			model.SongStats{
+				Verses:      1,
+				Lines:       1,
+				Words:       7,
+				UniqueWords: 5,
+				UniqueRatio: 0.714,
+				Lang:        "ru",
+				ReadingTime: 3 * time.Second,
+				WordFreq: []model.WordCount{
+					{Word: "не", Count: 2},
+					{Word: "я", Count: 2},
+					{Word: "и", Count: 1},
+					{Word: "лошадь", Count: 1},
+					{Word: "моя", Count: 1},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := analyzeText(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("analyzeText() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
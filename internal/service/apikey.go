@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"effective-mobile-go/internal/auth"
+	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/tracing"
+)
+
+// CreateAPIKey generates a new API key. The key itself is returned only once, it is
+// stored as a hash.
+func (s Service) CreateAPIKey(ctx context.Context, name string, roles []string) (model.APIKey, string, error) {
+	ctx, span := tracing.Start(ctx, "Service.CreateAPIKey")
+	defer span.End()
+
+	var zero model.APIKey
+
+	if name == "" || utf8.RuneCountInString(name) > 50 {
+		return zero, "", model.ErrBadRequest.WithField("name", "name is required and must be at most 50 characters")
+	}
+
+	for _, role := range roles {
+		if !model.IsValidRole(role) {
+			return zero, "", model.ErrBadRequest.WithField("roles", fmt.Sprintf("unknown role %q", role))
+		}
+	}
+
+	key, prefix, hash := auth.GenerateAPIKey()
+
+	apiKey, err := s.localRepo.CreateAPIKey(ctx, model.APIKey{
+		Name:   name,
+		Prefix: prefix,
+		Roles:  roles,
+	}, hash)
+
+	if err != nil {
+		return zero, "", err
+	}
+
+	getLogger(ctx).Info("api key created", "keyID", apiKey.ID, "name", name, "roles", roles)
+
+	return apiKey, key, nil
+}
+
+func (s Service) ListAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "Service.ListAPIKeys")
+	defer span.End()
+
+	return s.localRepo.ListAPIKeys(ctx)
+}
+
+func (s Service) RevokeAPIKey(ctx context.Context, keyID uint64) error {
+	ctx, span := tracing.Start(ctx, "Service.RevokeAPIKey")
+	defer span.End()
+
+	if err := s.localRepo.RevokeAPIKey(ctx, keyID); err != nil {
+		return err
+	}
+
+	getLogger(ctx).Info("api key revoked", "keyID", keyID)
+
+	return nil
+}
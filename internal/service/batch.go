@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"effective-mobile-go/internal/metrics"
+	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/tracing"
+)
+
+const maxBatchSize = 1000
+
+var errBatchAborted = errors.New("batch aborted")
+
+// batchTx are the options of the atomic batch. The items are applied again on retry,
+// so their results are overwritten.
+var batchTx = model.TxOptions{Retries: 3}
+
+// BatchCreateSongs creates the songs like CreateSong does. In atomic mode the remote lookups
+// are done first and then all songs are written in one transaction, so either all of them
+// are created or none (the items which didn't cause the failure are reported as aborted).
+// In best-effort mode every song is created independently. Returns true if anything was
+// written (or there was nothing to write).
+func (s Service) BatchCreateSongs(ctx context.Context, songs []model.SongDetail, atomic bool) ([]model.BatchResult, bool, error) {
+	ctx, span := tracing.Start(ctx, "Service.BatchCreateSongs")
+	defer span.End()
+
+	if err := checkBatchSize(len(songs)); err != nil {
+		return nil, false, err
+	}
+
+	results := make([]model.BatchResult, len(songs))
+	toCreate := make([]bool, len(songs))
+
+	// prepare: validate, check for existence and enrich
+	forEach(len(songs), defaultImportConcurrency, func(i int) {
+		song := songs[i]
+		results[i].Index = i
+
+		if reason := validateSong(song.Group, song.Name, song.Link); reason != "" {
+			setFailed(&results[i], reason)
+			return
+		}
+
+		list, err := s.localRepo.ListSongs(ctx, model.SongFilters{Name: &song.Name, Group: &song.Group})
+		if err != nil && !errors.Is(err, model.ErrNotFound) {
+			setFailed(&results[i], err.Error())
+			return
+		}
+
+		if len(list) > 0 {
+			setOK(&results[i], list[0])
+			return
+		}
+
+		remote, err := s.getRemoteSong(ctx, song)
+		if err != nil {
+			setFailed(&results[i], "enrichment failed: "+err.Error())
+			return
+		}
+
+		songs[i] = remote
+		toCreate[i] = true
+	})
+
+	create := func(ctx context.Context, i int) error {
+		song, err := s.localRepo.CreateSong(ctx, songs[i])
+		if err != nil {
+			setFailed(&results[i], err.Error())
+			return err
+		}
+		setOK(&results[i], song)
+		return nil
+	}
+
+	committed, err := s.runBatch(ctx, results, toCreate, atomic, create)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if committed {
+		for i := range results {
+			if toCreate[i] && results[i].Status == model.BatchOK {
+				metrics.SongCreated()
+				s.updateStats(ctx, results[i].ID, songs[i].Text, "")
+			}
+		}
+	}
+
+	return results, committed, nil
+}
+
+// BatchUpdateSongs updates the songs like UpdateSong does. See BatchCreateSongs for modes.
+func (s Service) BatchUpdateSongs(ctx context.Context, updates []model.SongUpdate, atomic bool) ([]model.BatchResult, bool, error) {
+	ctx, span := tracing.Start(ctx, "Service.BatchUpdateSongs")
+	defer span.End()
+
+	if err := checkBatchSize(len(updates)); err != nil {
+		return nil, false, err
+	}
+
+	results := make([]model.BatchResult, len(updates))
+	toUpdate := make([]bool, len(updates))
+
+	for i := range updates {
+		results[i].Index = i
+		results[i].ID = updates[i].ID
+		toUpdate[i] = true
+	}
+
+	texts := make([]string, len(updates))
+
+	update := func(ctx context.Context, i int) error {
+		song, err := s.localRepo.UpdateSong(ctx, updates[i])
+		if err != nil {
+			setFailed(&results[i], err.Error())
+			return err
+		}
+		texts[i] = song.Text
+		song.Text = ""
+		setOK(&results[i], song)
+		return nil
+	}
+
+	committed, err := s.runBatch(ctx, results, toUpdate, atomic, update)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if committed {
+		for i := range results {
+			if updates[i].Text != nil && results[i].Status == model.BatchOK {
+				s.updateStats(ctx, results[i].ID, texts[i], "")
+			}
+		}
+	}
+
+	return results, committed, nil
+}
+
+// BatchDeleteSongs deletes the songs like DeleteSong does. See BatchCreateSongs for modes.
+func (s Service) BatchDeleteSongs(ctx context.Context, ids []uint64, atomic bool) ([]model.BatchResult, bool, error) {
+	ctx, span := tracing.Start(ctx, "Service.BatchDeleteSongs")
+	defer span.End()
+
+	if err := checkBatchSize(len(ids)); err != nil {
+		return nil, false, err
+	}
+
+	results := make([]model.BatchResult, len(ids))
+	toDelete := make([]bool, len(ids))
+
+	for i := range ids {
+		results[i].Index = i
+		results[i].ID = ids[i]
+		toDelete[i] = true
+	}
+
+	del := func(ctx context.Context, i int) error {
+		if err := s.localRepo.DeleteSong(ctx, ids[i]); err != nil {
+			setFailed(&results[i], err.Error())
+			return err
+		}
+		results[i].Status = model.BatchOK
+		return nil
+	}
+
+	committed, err := s.runBatch(ctx, results, toDelete, atomic, del)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return results, committed, nil
+}
+
+// runBatch applies fn to the items marked in todo. In atomic mode nothing is applied if
+// any item has already failed, and all items are applied in one transaction. The items
+// which are not applied because of the failure of other ones are marked as aborted.
+func (s Service) runBatch(ctx context.Context, results []model.BatchResult, todo []bool, atomic bool,
+	fn func(context.Context, int) error) (committed bool, _ error) {
+
+	if !atomic {
+		for i := range results {
+			if todo[i] {
+				fn(ctx, i) // the result is set by fn
+			}
+		}
+		return true, nil
+	}
+
+	for i := range results {
+		if results[i].Status == model.BatchFailed {
+			abortBatch(results)
+			return false, nil
+		}
+	}
+
+	err := s.localRepo.WithTx(ctx, batchTx, func(ctx context.Context) error {
+		for i := range results {
+			if todo[i] {
+				if err := fn(ctx, i); err != nil {
+					return fmt.Errorf("%w: %w", errBatchAborted, err) // keep the cause for retry
+				}
+			}
+		}
+		return nil
+	})
+
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, errBatchAborted):
+		abortBatch(results)
+		return false, nil
+	default: // begin or commit failed
+		return false, err
+	}
+}
+
+func abortBatch(results []model.BatchResult) {
+	for i := range results {
+		if results[i].Status != model.BatchFailed {
+			results[i].Status = model.BatchAborted
+			results[i].Song = nil
+		}
+	}
+}
+
+func checkBatchSize(n int) error {
+	if n == 0 || n > maxBatchSize {
+		return model.ErrBadRequest.WithDetail(fmt.Sprintf("batch size must be 1..%d", maxBatchSize))
+	}
+	return nil
+}
+
+func setOK(res *model.BatchResult, song model.SongDetail) {
+	res.Status = model.BatchOK
+	res.Reason = ""
+	res.ID = song.ID
+	res.Song = &song
+}
+
+func setFailed(res *model.BatchResult, reason string) {
+	res.Status = model.BatchFailed
+	res.Song = nil
+	res.Reason = reason
+}
+
+// forEach calls fn(i) for i in [0, n) using at most concurrency goroutines.
+func forEach(n, concurrency int, fn func(i int)) {
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+
+	for i := range n {
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			fn(i)
+		}()
+	}
+
+	wg.Wait()
+}
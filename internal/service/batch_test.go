@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"effective-mobile-go/internal/config"
+	"effective-mobile-go/internal/model"
+)
+
+func TestService_BatchCreateSongs(t *testing.T) {
+
+	songs := func() []model.SongDetail {
+		return []model.SongDetail{
+			{Group: "Muse", Name: "Uprising"},
+			{Group: "Unknown", Name: "Nothing"}, // not found remotely
+			{Group: "Muse", Name: "Hysteria"},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		atomic        bool
+		wantCommitted bool
+		wantStatuses  []model.BatchStatus
+		wantCount     int
+	}{
+		{
+			"atomic",
+			true,
+			false,
+			[]model.BatchStatus{model.BatchAborted, model.BatchFailed, model.BatchAborted},
+			0,
+		},
+		{
+			"best effort",
+			false,
+			true,
+			[]model.BatchStatus{model.BatchOK, model.BatchFailed, model.BatchOK},
+			2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeLocalRepo()
+			s := New(repo, fakeRemoteRepo{}, config.Service{})
+
+			results, committed, err := s.BatchCreateSongs(context.Background(), songs(), tt.atomic)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if committed != tt.wantCommitted {
+				t.Errorf("committed = %v, want %v", committed, tt.wantCommitted)
+			}
+
+			for i, want := range tt.wantStatuses {
+				if got := results[i].Status; got != want {
+					t.Errorf("results[%d].Status = %q, want %q", i, got, want)
+				}
+			}
+
+			if got := repo.count(); got != tt.wantCount {
+				t.Errorf("songs count = %d, want %d", got, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestService_BatchUpdateSongs_atomicRollback(t *testing.T) {
+
+	repo := newFakeLocalRepo(model.SongDetail{Group: "Muse", Name: "Uprising", Text: "old"})
+	s := New(repo, fakeRemoteRepo{}, config.Service{})
+
+	text := "new"
+	results, committed, err := s.BatchUpdateSongs(context.Background(), []model.SongUpdate{
+		{ID: 1, Text: &text},
+		{ID: 42, Text: &text}, // not found
+	}, true)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if committed {
+		t.Error("committed = true, want false")
+	}
+
+	if results[0].Status != model.BatchAborted || results[1].Status != model.BatchFailed {
+		t.Errorf("statuses = %q, %q, want aborted, failed", results[0].Status, results[1].Status)
+	}
+
+	if song, _ := repo.GetSong(context.Background(), 1); song.Text != "old" {
+		t.Errorf("text = %q, want %q (rolled back)", song.Text, "old")
+	}
+}
+
+func TestService_BatchDeleteSongs_size(t *testing.T) {
+	s := New(newFakeLocalRepo(), fakeRemoteRepo{}, config.Service{})
+
+	for _, n := range []int{0, maxBatchSize + 1} {
+		if _, _, err := s.BatchDeleteSongs(context.Background(), make([]uint64, n), true); err == nil {
+			t.Errorf("size %d: error expected", n)
+		}
+	}
+}
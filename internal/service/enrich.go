@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/tracing"
+)
+
+// EnrichSongs looks the songs matching the filters up in the remote repo again and fills
+// their missing release, text and link (all of them if opts.Overwrite, the songs which
+// have all fields are skipped otherwise). At most opts.Concurrency songs are processed at
+// once. In dry-run mode nothing is written. A song error does not stop the others, it is
+// reported in the song result. The results are ordered by ID. If ctx is done, the results
+// of the songs processed so far are returned with ctx.Err().
+func (s Service) EnrichSongs(ctx context.Context, req model.SongFilters, opts model.EnrichOptions) (_ []model.EnrichResult, err error) {
+	ctx, span := tracing.Start(ctx, "Service.EnrichSongs")
+	defer func() { tracing.End(span, err) }()
+
+	// the songs are collected first, so the cursor isn't held during the remote calls
+	var songs []model.SongDetail
+
+	err = s.localRepo.ExportSongs(ctx, req, true, func(song model.SongDetail) error {
+		if opts.Overwrite || song.Release.IsZero() || song.Text == "" || song.Link == "" {
+			songs = append(songs, song)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultImportConcurrency
+	}
+	concurrency = min(concurrency, maxImportConcurrency)
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]model.EnrichResult, len(songs))
+		sem     = make(chan struct{}, concurrency)
+		started = 0
+	)
+
+	for i, song := range songs {
+
+		sem <- struct{}{}
+
+		if ctx.Err() != nil {
+			<-sem
+			break
+		}
+
+		started++
+		wg.Add(1)
+
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			results[i] = s.enrichSong(ctx, song, opts)
+		}()
+	}
+
+	wg.Wait()
+
+	results = results[:started]
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+
+	return results, ctx.Err()
+}
+
+func (s Service) enrichSong(ctx context.Context, song model.SongDetail, opts model.EnrichOptions) model.EnrichResult {
+
+	res := model.EnrichResult{ID: song.ID, Group: song.Group, Song: song.Name}
+
+	remote, err := s.getRemoteSong(ctx, model.SongDetail{Name: song.Name, Group: song.Group})
+	if err != nil {
+		res.Status, res.Reason = model.EnrichFailed, "enrichment failed: "+err.Error()
+		return res
+	}
+
+	update := model.SongUpdate{ID: song.ID}
+
+	if !remote.Release.IsZero() && !remote.Release.Equal(song.Release.Time) && (opts.Overwrite || song.Release.IsZero()) {
+		update.Release = &remote.Release
+		res.Fields = append(res.Fields, "release")
+	}
+	if remote.Text != "" && remote.Text != song.Text && (opts.Overwrite || song.Text == "") {
+		update.Text = &remote.Text
+		res.Fields = append(res.Fields, "text")
+	}
+	if remote.Link != "" && remote.Link != song.Link && (opts.Overwrite || song.Link == "") {
+		if reason := validateSong(song.Group, song.Name, remote.Link); reason != "" {
+			res.Status, res.Reason = model.EnrichFailed, reason
+			return res
+		}
+		update.Link = &remote.Link
+		res.Fields = append(res.Fields, "link")
+	}
+
+	switch {
+	case len(res.Fields) == 0:
+		res.Status = model.EnrichUnchanged
+		return res
+	case opts.DryRun:
+		res.Status = model.EnrichChanged
+		return res
+	}
+
+	if _, err := s.localRepo.UpdateSong(ctx, update); err != nil {
+		res.Status, res.Reason = model.EnrichFailed, err.Error()
+		return res
+	}
+
+	if update.Text != nil {
+		s.updateStats(ctx, song.ID, *update.Text, "")
+	}
+
+	res.Status = model.EnrichUpdated
+	return res
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"effective-mobile-go/internal/config"
+	"effective-mobile-go/internal/model"
+)
+
+func TestService_EnrichSongs(t *testing.T) {
+
+	songs := func() []model.SongDetail {
+		return []model.SongDetail{
+			{Group: "Muse", Name: "Uprising"},
+			{Group: "Muse", Name: "Hysteria", Text: "own text", Link: "https://example.com/own"}, // no release remotely either
+			{Group: "Unknown", Name: "Song"},
+			{Group: "Muse", Name: "Starlight", Text: "own text"},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		opts       model.EnrichOptions
+		want       []model.EnrichStatus
+		wantFields [][]string
+		wantText   string // of Uprising
+	}{
+		{
+			name:       "missing",
+			want:       []model.EnrichStatus{model.EnrichUpdated, model.EnrichUnchanged, model.EnrichFailed, model.EnrichUpdated},
+			wantFields: [][]string{{"text", "link"}, nil, nil, {"link"}},
+			wantText:   "Uprising text",
+		},
+		{
+			name:       "dry run",
+			opts:       model.EnrichOptions{DryRun: true},
+			want:       []model.EnrichStatus{model.EnrichChanged, model.EnrichUnchanged, model.EnrichFailed, model.EnrichChanged},
+			wantFields: [][]string{{"text", "link"}, nil, nil, {"link"}},
+		},
+		{
+			name:       "overwrite",
+			opts:       model.EnrichOptions{Overwrite: true},
+			want:       []model.EnrichStatus{model.EnrichUpdated, model.EnrichUpdated, model.EnrichFailed, model.EnrichUpdated},
+			wantFields: [][]string{{"text", "link"}, {"text", "link"}, nil, {"text", "link"}},
+			wantText:   "Uprising text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			repo := newFakeLocalRepo(songs()...)
+			s := New(repo, fakeRemoteRepo{}, config.Service{})
+
+			results, err := s.EnrichSongs(context.Background(), model.SongFilters{}, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(results) != len(tt.want) {
+				t.Fatalf("results = %+v, want %d", results, len(tt.want))
+			}
+
+			for i, want := range tt.want {
+				if got := results[i].Status; got != want {
+					t.Errorf("results[%d].Status = %q, want %q: %s", i, got, want, results[i].Reason)
+				}
+				if got := results[i].Fields; !slices.Equal(got, tt.wantFields[i]) {
+					t.Errorf("results[%d].Fields = %v, want %v", i, got, tt.wantFields[i])
+				}
+			}
+
+			song, _ := repo.GetSong(context.Background(), 1)
+			if song.Text != tt.wantText {
+				t.Errorf("text = %q, want %q", song.Text, tt.wantText)
+			}
+		})
+	}
+}
+
+// cancelingLocalRepo cancels the context after the first update.
+type cancelingLocalRepo struct {
+	*fakeLocalRepo
+	cancel context.CancelFunc
+}
+
+func (r cancelingLocalRepo) UpdateSong(ctx context.Context, req model.SongUpdate) (model.SongDetail, error) {
+	defer r.cancel()
+	return r.fakeLocalRepo.UpdateSong(ctx, req)
+}
+
+func TestService_EnrichSongs_canceled(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo := newFakeLocalRepo(
+		model.SongDetail{Group: "Muse", Name: "Uprising"},
+		model.SongDetail{Group: "Muse", Name: "Hysteria"},
+		model.SongDetail{Group: "Muse", Name: "Starlight"},
+	)
+	s := New(cancelingLocalRepo{repo, cancel}, fakeRemoteRepo{}, config.Service{})
+
+	results, err := s.EnrichSongs(ctx, model.SongFilters{}, model.EnrichOptions{Concurrency: 1})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want %v", err, context.Canceled)
+	}
+
+	// the update written before the cancel is reported
+	if len(results) != 1 || results[0].Status != model.EnrichUpdated {
+		t.Fatalf("results = %+v, want 1 updated", results)
+	}
+
+	song, _ := repo.GetSong(ctx, results[0].ID)
+	if song.Text == "" {
+		t.Errorf("song %d is not updated", results[0].ID)
+	}
+}
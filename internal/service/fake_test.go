@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"maps"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"effective-mobile-go/internal/model"
+)
+
+// fakeLocalRepo is in-memory LocalRepo. WithTx restores the songs if fn fails.
+type fakeLocalRepo struct {
+	LocalRepo // not implemented methods panic
+
+	mu     sync.Mutex
+	songs  map[uint64]model.SongDetail
+	lyrics map[uint64][]model.SongLyrics
+	stats  map[uint64]model.SongStats
+	lastID uint64
+	inTx   atomic.Int32 // open transactions
+}
+
+func newFakeLocalRepo(songs ...model.SongDetail) *fakeLocalRepo {
+	r := &fakeLocalRepo{songs: map[uint64]model.SongDetail{}, stats: map[uint64]model.SongStats{}}
+	for _, song := range songs {
+		r.CreateSong(context.Background(), song)
+	}
+	return r
+}
+
+func (r *fakeLocalRepo) CreateSong(_ context.Context, song model.SongDetail) (model.SongDetail, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, v := range r.songs {
+		if v.Name == song.Name && v.Group == song.Group {
+			return v, nil
+		}
+	}
+
+	r.lastID++
+	song.ID = r.lastID
+	r.songs[song.ID] = song
+
+	return song, nil
+}
+
+func (r *fakeLocalRepo) ListSongs(_ context.Context, req model.SongFilters) ([]model.SongDetail, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var list []model.SongDetail
+	for _, v := range r.songs {
+		if (req.Name == nil || *req.Name == v.Name) && (req.Group == nil || *req.Group == v.Group) {
+			list = append(list, v)
+		}
+	}
+
+	return list, nil
+}
+
+func (r *fakeLocalRepo) GetSong(_ context.Context, songID uint64) (model.SongDetail, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	song, ok := r.songs[songID]
+	if !ok {
+		return song, model.ErrNotFound
+	}
+
+	return song, nil
+}
+
+func (r *fakeLocalRepo) UpdateSong(_ context.Context, req model.SongUpdate) (model.SongDetail, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	song, ok := r.songs[req.ID]
+	if !ok {
+		return song, model.ErrNotFound
+	}
+
+	if req.Release != nil {
+		song.Release = *req.Release
+	}
+	if req.Text != nil {
+		song.Text = *req.Text
+	}
+	if req.Link != nil {
+		song.Link = *req.Link
+	}
+	r.songs[req.ID] = song
+
+	return song, nil
+}
+
+func (r *fakeLocalRepo) ExportSongs(ctx context.Context, req model.SongFilters, _ bool, fn func(model.SongDetail) error) error {
+
+	list, _ := r.ListSongs(ctx, req)
+
+	for _, song := range list {
+		if err := fn(song); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *fakeLocalRepo) DeleteSong(_ context.Context, songID uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.songs, songID)
+	return nil
+}
+
+func (r *fakeLocalRepo) ListSongLyrics(_ context.Context, songID uint64) ([]model.SongLyrics, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.lyrics[songID], nil
+}
+
+func (r *fakeLocalRepo) PutSongStats(_ context.Context, stats model.SongStats) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stats[stats.SongID] = stats
+	return nil
+}
+
+func (r *fakeLocalRepo) GetSongStats(_ context.Context, songID uint64) (model.SongStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats, ok := r.stats[songID]
+	if !ok {
+		return stats, model.ErrNotFound
+	}
+
+	return stats, nil
+}
+
+func (r *fakeLocalRepo) WithTx(ctx context.Context, _ model.TxOptions, fn func(context.Context) error) error {
+
+	r.inTx.Add(1)
+	defer r.inTx.Add(-1)
+
+	r.mu.Lock()
+	saved := maps.Clone(r.songs)
+	r.mu.Unlock()
+
+	if err := fn(ctx); err != nil {
+		r.mu.Lock()
+		r.songs = saved
+		r.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+func (r *fakeLocalRepo) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.songs)
+}
+
+// fakeRemoteRepo knows the songs of the Muse group only. Like a real one, it is not
+// sensitive to the case of the group name and returns the group and the name as asked.
+type fakeRemoteRepo struct{}
+
+func (fakeRemoteRepo) GetSong(_ context.Context, song model.SongDetail) (model.SongDetail, error) {
+	if !strings.EqualFold(strings.TrimSpace(song.Group), "Muse") {
+		return model.SongDetail{}, model.ErrNotFound
+	}
+	song.Text = song.Name + " text"
+	song.Link = "https://example.com/" + song.Name
+	return song, nil
+}
+
+func (r *fakeLocalRepo) LockSong(context.Context, string, string) error {
+	return nil
+}
+
+// countingRemoteRepo counts the calls and holds every call for delay, so that concurrent
+// lookups overlap.
+type countingRemoteRepo struct {
+	fakeRemoteRepo
+	delay time.Duration
+	calls atomic.Int32
+}
+
+func (r *countingRemoteRepo) GetSong(ctx context.Context, song model.SongDetail) (model.SongDetail, error) {
+	r.calls.Add(1)
+	time.Sleep(r.delay)
+	return r.fakeRemoteRepo.GetSong(ctx, song)
+}
+
+type remoteRepoFunc func(context.Context, model.SongDetail) (model.SongDetail, error)
+
+func (fn remoteRepoFunc) GetSong(ctx context.Context, song model.SongDetail) (model.SongDetail, error) {
+	return fn(ctx, song)
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"effective-mobile-go/internal/metrics"
+	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/songio"
+	"effective-mobile-go/internal/tracing"
+)
+
+const (
+	defaultImportConcurrency = 4
+	maxImportConcurrency     = 32
+)
+
+// ImportSongs creates songs read from r. The rows with missing release, text or link are
+// enriched through the remote repo, at most opts.Concurrency rows are processed at once.
+// In dry-run mode the rows are validated and checked for existence only: nothing is
+// written and the remote repo is not called. A row error does not stop the import, it
+// is reported in the row result. The results are ordered by rows. If the import is
+// interrupted (the input is malformed, can't be read or ctx is done), the results of the
+// rows processed so far are returned with the error; the malformed input is ErrBadRequest.
+func (s Service) ImportSongs(ctx context.Context, r songio.Reader, opts model.ImportOptions) (results []model.ImportResult, err error) {
+	ctx, span := tracing.Start(ctx, "Service.ImportSongs")
+	defer func() { tracing.End(span, err) }()
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultImportConcurrency
+	}
+	concurrency = min(concurrency, maxImportConcurrency)
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		sem = make(chan struct{}, concurrency)
+	)
+
+	report := func(res model.ImportResult) {
+		mu.Lock()
+		results = append(results, res)
+		mu.Unlock()
+	}
+
+	for ctx.Err() == nil {
+
+		var row model.ImportRow
+		row, err = r.Read()
+
+		if err == io.EOF {
+			err = nil
+			break
+		}
+
+		if err != nil {
+			var rowErr *songio.RowError
+			if !errors.As(err, &rowErr) {
+				break
+			}
+
+			report(failed(row, rowErr.Err.Error()))
+			err = nil
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			report(s.importRow(ctx, row, opts.DryRun))
+		}()
+	}
+
+	wg.Wait()
+
+	if err == nil {
+		err = ctx.Err()
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Row < results[j].Row
+	})
+
+	if err != nil {
+		getLogger(ctx).Debug("import interrupted", "rows", len(results), "error", err)
+	}
+
+	if errors.Is(err, songio.ErrMalformed) {
+		err = model.ErrBadRequest.WithDetail(err.Error()).Wrap(err)
+	}
+
+	return results, err
+}
+
+func (s Service) importRow(ctx context.Context, row model.ImportRow, dryRun bool) model.ImportResult {
+
+	if reason := validateSong(row.Group, row.Song, row.Link); reason != "" {
+		return failed(row, reason)
+	}
+
+	list, err := s.localRepo.ListSongs(ctx, model.SongFilters{
+		Name:  &row.Song,
+		Group: &row.Group,
+	})
+
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		return failed(row, err.Error())
+	}
+
+	if len(list) > 0 {
+		return model.ImportResult{
+			Row:    row.Row,
+			Group:  row.Group,
+			Song:   row.Song,
+			ID:     list[0].ID,
+			Status: model.ImportExisting,
+		}
+	}
+
+	if dryRun {
+		return model.ImportResult{
+			Row:    row.Row,
+			Group:  row.Group,
+			Song:   row.Song,
+			Status: model.ImportValid,
+		}
+	}
+
+	song := model.SongDetail{
+		Name:    row.Song,
+		Group:   row.Group,
+		Release: row.Release,
+		Text:    row.Text,
+		Link:    row.Link,
+	}
+
+	if song.Release.IsZero() || song.Text == "" || song.Link == "" {
+
+		remote, err := s.getRemoteSong(ctx, model.SongDetail{Name: row.Song, Group: row.Group})
+		if err != nil {
+			return failed(row, "enrichment failed: "+err.Error())
+		}
+
+		// fill only missing fields, the file data have priority
+		if song.Release.IsZero() {
+			song.Release = remote.Release
+		}
+		if song.Text == "" {
+			song.Text = remote.Text
+		}
+		if song.Link == "" {
+			song.Link = remote.Link
+		}
+	}
+
+	text := song.Text
+
+	song, err = s.localRepo.CreateSong(ctx, song)
+	if err != nil {
+		return failed(row, err.Error())
+	}
+
+	metrics.SongCreated()
+	s.updateStats(ctx, song.ID, text, "")
+
+	return model.ImportResult{
+		Row:    row.Row,
+		Group:  row.Group,
+		Song:   row.Song,
+		ID:     song.ID,
+		Status: model.ImportCreated,
+	}
+}
+
+// validateSong returns the reason why the song can't be stored or "" if it can.
+// The limits match the DB columns.
+func validateSong(group, song, link string) string {
+	switch {
+	case group == "":
+		return "group is required"
+	case song == "":
+		return "song is required"
+	case utf8.RuneCountInString(group) > 50:
+		return "group must be at most 50 characters"
+	case utf8.RuneCountInString(song) > 50:
+		return "song must be at most 50 characters"
+	case utf8.RuneCountInString(link) > 256:
+		return "link must be at most 256 characters"
+	}
+	return ""
+}
+
+func failed(row model.ImportRow, reason string) model.ImportResult {
+	return model.ImportResult{
+		Row:    row.Row,
+		Group:  row.Group,
+		Song:   row.Song,
+		Status: model.ImportFailed,
+		Reason: reason,
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"effective-mobile-go/internal/config"
+	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/songio"
+)
+
+func TestService_ImportSongs_interrupted(t *testing.T) {
+
+	errRead := errors.New("connection reset")
+
+	tests := []struct {
+		name       string
+		input      io.Reader
+		wantErr    error
+		badRequest bool
+	}{
+		{
+			"malformed",
+			strings.NewReader(`[{"group":"Muse","song":"Uprising"},{"group":"Muse","song":"Hysteria"},}`),
+			songio.ErrMalformed,
+			true,
+		},
+		{
+			"read error",
+			io.MultiReader(strings.NewReader(`[{"group":"Muse","song":"Uprising"},{"group":"Muse","song":"Hysteria"},`), iotest.ErrReader(errRead)),
+			errRead,
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			localRepo := newFakeLocalRepo()
+			s := New(localRepo, fakeRemoteRepo{}, config.Service{})
+
+			r, err := songio.NewReader(songio.JSON, tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			results, err := s.ImportSongs(context.Background(), r, model.ImportOptions{})
+
+			if !errors.Is(err, tt.wantErr) || errors.Is(err, model.ErrBadRequest) != tt.badRequest {
+				t.Fatalf("error = %v, want %v (bad request %t)", err, tt.wantErr, tt.badRequest)
+			}
+
+			// the rows written before the error are reported
+			if len(results) != 2 || results[0].Status != model.ImportCreated || results[1].Status != model.ImportCreated {
+				t.Fatalf("results = %+v, want 2 rows created", results)
+			}
+
+			if got := localRepo.count(); got != 2 {
+				t.Errorf("songs count = %d, want 2", got)
+			}
+		})
+	}
+}
+
+func TestService_ImportSongs_canceled(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := New(newFakeLocalRepo(), fakeRemoteRepo{}, config.Service{})
+
+	r := readerFunc(func() (model.ImportRow, error) {
+		cancel() // after the first row
+		return model.ImportRow{Row: 1, Group: "Muse", Song: "Uprising"}, nil
+	})
+
+	results, err := s.ImportSongs(ctx, r, model.ImportOptions{})
+
+	if !errors.Is(err, context.Canceled) || errors.Is(err, model.ErrBadRequest) {
+		t.Fatalf("error = %v, want %v", err, context.Canceled)
+	}
+
+	if len(results) != 1 {
+		t.Fatalf("results = %+v, want the first row", results)
+	}
+}
+
+type readerFunc func() (model.ImportRow, error)
+
+func (fn readerFunc) Read() (model.ImportRow, error) { return fn() }
@@ -2,10 +2,16 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"strings"
 
+	"golang.org/x/sync/singleflight"
+
+	"effective-mobile-go/internal/config"
+	"effective-mobile-go/internal/metrics"
 	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/tracing"
 )
 
 type LocalRepo interface {
@@ -14,6 +20,17 @@ type LocalRepo interface {
 	GetSong(_ context.Context, songID uint64) (model.SongDetail, error)
 	UpdateSong(context.Context, model.SongUpdate) (model.SongDetail, error)
 	DeleteSong(_ context.Context, songID uint64) error
+	ListSongLyrics(_ context.Context, songID uint64) ([]model.SongLyrics, error)
+	PutSongLyrics(context.Context, model.SongLyrics) (model.SongLyrics, error)
+	DeleteSongLyrics(_ context.Context, songID uint64, lang string) error
+	PutSongStats(context.Context, model.SongStats) error
+	GetSongStats(_ context.Context, songID uint64) (model.SongStats, error)
+	ExportSongs(_ context.Context, _ model.SongFilters, withText bool, fn func(model.SongDetail) error) error
+	WithTx(_ context.Context, _ model.TxOptions, fn func(context.Context) error) error
+	LockSong(_ context.Context, group, name string) error
+	CreateAPIKey(_ context.Context, _ model.APIKey, hash []byte) (model.APIKey, error)
+	ListAPIKeys(context.Context) ([]model.APIKey, error)
+	RevokeAPIKey(_ context.Context, keyID uint64) error
 }
 
 type RemoteRepo interface {
@@ -21,46 +38,172 @@ type RemoteRepo interface {
 }
 
 type Service struct {
-	localRepo  LocalRepo
-	remoteRepo RemoteRepo
+	localRepo    LocalRepo
+	remoteRepo   RemoteRepo
+	remoteCalls  *singleflight.Group // coalesces concurrent remote lookups of the same song
+	advisoryLock bool
 }
 
-func New(localRepo LocalRepo, remoteRepo RemoteRepo) Service {
+func New(localRepo LocalRepo, remoteRepo RemoteRepo, cfg config.Service) Service {
 	return Service{
-		localRepo:  localRepo,
-		remoteRepo: remoteRepo,
+		localRepo:    localRepo,
+		remoteRepo:   remoteRepo,
+		remoteCalls:  &singleflight.Group{},
+		advisoryLock: cfg.AdvisoryLock,
 	}
 }
 
+// createSongTx are the options of the check-then-insert unit of work. With serializable
+// isolation concurrent creates of the same song conflict (and are retried) instead of
+// racing between the check and the insert. The advisory lock makes them wait for each
+// other instead, so read committed is enough then.
+var (
+	createSongTx       = model.TxOptions{Isolation: sql.LevelSerializable, Retries: 3}
+	createSongLockedTx = model.TxOptions{Retries: 3}
+)
+
+// CreateSong returns the song if it is already in the library, otherwise looks it up
+// in the remote repo and stores it. Concurrent creates of the same song share one remote
+// lookup within the instance. The lookups are done outside of the transaction, so it
+// doesn't hold the connection during the remote call; only the check and the insert are.
 func (s Service) CreateSong(ctx context.Context, song model.SongDetail) (model.SongDetail, error) {
+	ctx, span := tracing.Start(ctx, "Service.CreateSong")
+	defer span.End()
+
 	var zero model.SongDetail
 
-	list, err := s.localRepo.ListSongs(ctx, model.SongFilters{
-		Name:  &song.Name,
-		Group: &song.Group,
-	})
+	found, err := s.findSong(ctx, song.Group, song.Name)
+	if err != nil {
+		return zero, err
+	}
 
-	if err != nil && !errors.Is(err, model.ErrNotFound) {
+	if found != nil {
+		return *found, nil
+	}
+
+	remote, err := s.getRemoteSong(ctx, song)
+	if err != nil {
 		return zero, err
 	}
 
-	if len(list) > 0 {
-		return list[0], nil
+	opts := createSongTx
+	if s.advisoryLock {
+		opts = createSongLockedTx
 	}
 
-	song, err = s.remoteRepo.GetSong(ctx, song)
+	created := false
+
+	err = s.localRepo.WithTx(ctx, opts, func(ctx context.Context) error {
+
+		if s.advisoryLock {
+			if err := s.localRepo.LockSong(ctx, song.Group, song.Name); err != nil {
+				return err
+			}
+		}
+
+		// a concurrent create could store it during the remote call
+		found, err := s.findSong(ctx, song.Group, song.Name)
+		if err != nil {
+			return err
+		}
+
+		if found != nil {
+			song, created = *found, false
+			return nil
+		}
+
+		song, err = s.localRepo.CreateSong(ctx, remote)
+		if err != nil {
+			return err
+		}
+
+		created = true
+		return nil
+	})
+
 	if err != nil {
 		return zero, err
 	}
 
-	return s.localRepo.CreateSong(ctx, song)
+	if created {
+		metrics.SongCreated()
+		s.updateStats(ctx, song.ID, remote.Text, "")
+	}
+
+	return song, nil
+}
+
+// findSong returns the song by the exact group and name or nil if there is none.
+func (s Service) findSong(ctx context.Context, group, name string) (*model.SongDetail, error) {
+
+	list, err := s.localRepo.ListSongs(ctx, model.SongFilters{
+		Name:  &name,
+		Group: &group,
+	})
+
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		return nil, err
+	}
+
+	if len(list) == 0 {
+		return nil, nil
+	}
+
+	return &list[0], nil
+}
+
+// getRemoteSong looks the song up in the remote repo. Concurrent lookups of the same
+// song (up to case and surrounding spaces, like LockSong) are coalesced into one remote
+// call; every caller gets the result with its own group and name. The call is not
+// canceled if the caller which started it gives up, the others may still wait for it.
+func (s Service) getRemoteSong(ctx context.Context, song model.SongDetail) (model.SongDetail, error) {
+
+	key := songKey(song.Group, song.Name)
+
+	ch := s.remoteCalls.DoChan(key, func() (any, error) {
+		return s.remoteRepo.GetSong(context.WithoutCancel(ctx), song)
+	})
+
+	select {
+	case <-ctx.Done():
+		return model.SongDetail{}, ctx.Err()
+	case res := <-ch:
+		if res.Shared {
+			getLogger(ctx).Debug("remote lookup shared", "key", key)
+		}
+		if res.Err != nil {
+			metrics.EnrichmentFailed(errors.Is(res.Err, model.ErrNotFound))
+			return model.SongDetail{}, res.Err
+		}
+		remote := res.Val.(model.SongDetail)
+		remote.Group, remote.Name = song.Group, song.Name
+		return remote, nil
+	}
+}
+
+// songKey returns the normalized song identity.
+func songKey(group, name string) string {
+	return strings.ToLower(strings.TrimSpace(group)) + "\x00" + strings.ToLower(strings.TrimSpace(name))
 }
 
 func (s Service) ListSongs(ctx context.Context, req model.SongFilters) ([]model.SongDetail, error) {
+	ctx, span := tracing.Start(ctx, "Service.ListSongs")
+	defer span.End()
+
 	return s.localRepo.ListSongs(ctx, req)
 }
 
+// ExportSongs calls fn for each song matching the filters as they are read from DB.
+func (s Service) ExportSongs(ctx context.Context, req model.SongFilters, withText bool, fn func(model.SongDetail) error) error {
+	ctx, span := tracing.Start(ctx, "Service.ExportSongs")
+	defer span.End()
+
+	return s.localRepo.ExportSongs(ctx, req, withText, fn)
+}
+
 func (s Service) GetSong(ctx context.Context, id uint64) (model.SongDetail, error) {
+	ctx, span := tracing.Start(ctx, "Service.GetSong")
+	defer span.End()
 
 	song, err := s.localRepo.GetSong(ctx, id)
 	if err != nil {
@@ -72,32 +215,168 @@ func (s Service) GetSong(ctx context.Context, id uint64) (model.SongDetail, erro
 	return song, nil
 }
 
-func (s Service) GetSongText(ctx context.Context, req model.GetSongTextRequest) (verses []string, _ error) {
+func (s Service) GetSongText(ctx context.Context, req model.GetSongTextRequest) (model.SongText, error) {
+	ctx, span := tracing.Start(ctx, "Service.GetSongText")
+	defer span.End()
+
+	var zero model.SongText
 
 	song, err := s.localRepo.GetSong(ctx, req.ID)
 	if err != nil {
-		return nil, err
+		return zero, err
+	}
+
+	list, err := s.localRepo.ListSongLyrics(ctx, req.ID)
+	if err != nil {
+		return zero, err
+	}
+
+	// the song text is the original even if its language is unknown
+	original := model.SongLyrics{SongID: song.ID, Original: true, Text: song.Text}
+	for _, v := range list {
+		if v.Original {
+			original = v
+			break
+		}
+	}
+
+	var lyrics model.SongLyrics
+
+	switch {
+	case req.Lang != "":
+		v, ok := matchLang(req.Lang, original, list)
+		if !ok {
+			return zero, model.ErrNotFound
+		}
+		lyrics = v
+	default:
+		lyrics = original
+		for _, lang := range req.AcceptLanguages {
+			if v, ok := matchLang(lang, original, list); ok {
+				lyrics = v
+				break
+			}
+		}
+	}
+
+	text := model.SongText{
+		Lang:       lyrics.Lang,
+		Original:   lyrics.Original,
+		Translator: lyrics.Translator,
+		Verses:     paginate(splitVerses(lyrics.Text), req.Offset, req.Limit),
 	}
 
-	verses = strings.Split(strings.TrimSpace(song.Text), "\n\n")
+	if req.SideBySide && !lyrics.Original {
+		originalVerses := paginate(splitVerses(original.Text), req.Offset, req.Limit)
+
+		// align verses so that they can be shown in pairs
+		n := max(len(text.Verses), len(originalVerses))
+		text.Verses = append(text.Verses, make([]string, n-len(text.Verses))...)
+		text.OriginalVerses = append(originalVerses, make([]string, n-len(originalVerses))...)
+	}
+
+	return text, nil
+}
 
-	if req.Offset != nil {
-		offset := min(*req.Offset, uint64(len(verses)))
+// matchLang looks for the lyrics in the lang language. The exact match is preferred,
+// then the match by the primary subtag in both directions (en-US -> en, en -> en-US).
+// The "*" matches the original.
+func matchLang(lang string, original model.SongLyrics, list []model.SongLyrics) (model.SongLyrics, bool) {
+
+	if lang == "*" {
+		return original, true
+	}
+
+	for _, v := range list {
+		if v.Lang == lang {
+			return v, true
+		}
+	}
+
+	base := model.BaseLang(lang)
+	for _, v := range list {
+		if model.BaseLang(v.Lang) == base {
+			return v, true
+		}
+	}
+
+	return model.SongLyrics{}, false
+}
+
+func splitVerses(text string) []string {
+	return strings.Split(strings.TrimSpace(text), "\n\n")
+}
+
+func paginate(verses []string, offset, limit *uint64) []string {
+
+	if offset != nil {
+		offset := min(*offset, uint64(len(verses)))
 		verses = verses[offset:]
 	}
 
-	if req.Limit != nil {
-		limit := min(*req.Limit, uint64(len(verses)))
+	if limit != nil {
+		limit := min(*limit, uint64(len(verses)))
 		verses = verses[:limit]
 	}
 
-	return verses, nil
+	return verses
+}
+
+func (s Service) ListSongLyrics(ctx context.Context, songID uint64) ([]model.SongLyrics, error) {
+	ctx, span := tracing.Start(ctx, "Service.ListSongLyrics")
+	defer span.End()
+
+	if _, err := s.localRepo.GetSong(ctx, songID); err != nil {
+		return nil, err
+	}
+
+	return s.localRepo.ListSongLyrics(ctx, songID)
+}
+
+func (s Service) PutSongLyrics(ctx context.Context, lyrics model.SongLyrics) (model.SongLyrics, error) {
+	ctx, span := tracing.Start(ctx, "Service.PutSongLyrics")
+	defer span.End()
+
+	text := lyrics.Text
+
+	lyrics, err := s.localRepo.PutSongLyrics(ctx, lyrics)
+	if err != nil {
+		return lyrics, err
+	}
+
+	if lyrics.Original {
+		s.updateStats(ctx, lyrics.SongID, text, lyrics.Lang)
+	}
+
+	return lyrics, nil
+}
+
+func (s Service) DeleteSongLyrics(ctx context.Context, songID uint64, lang string) error {
+	ctx, span := tracing.Start(ctx, "Service.DeleteSongLyrics")
+	defer span.End()
+
+	return s.localRepo.DeleteSongLyrics(ctx, songID, lang)
 }
 
 func (s Service) UpdateSong(ctx context.Context, req model.SongUpdate) (model.SongDetail, error) {
-	return s.localRepo.UpdateSong(ctx, req)
+	ctx, span := tracing.Start(ctx, "Service.UpdateSong")
+	defer span.End()
+
+	song, err := s.localRepo.UpdateSong(ctx, req)
+	if err != nil {
+		return song, err
+	}
+
+	if req.Text != nil {
+		s.updateStats(ctx, song.ID, song.Text, "")
+	}
+
+	return song, nil
 }
 
 func (s Service) DeleteSong(ctx context.Context, id uint64) error {
+	ctx, span := tracing.Start(ctx, "Service.DeleteSong")
+	defer span.End()
+
 	return s.localRepo.DeleteSong(ctx, id)
 }
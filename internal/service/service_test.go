@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"effective-mobile-go/internal/config"
+	"effective-mobile-go/internal/model"
+)
+
+func TestService_CreateSong_concurrent(t *testing.T) {
+	tests := []struct {
+		name      string
+		songs     []model.SongDetail
+		wantErr   error
+		wantCalls int32
+		wantCount int
+	}{
+		{
+			"same song",
+			[]model.SongDetail{{Group: "Muse", Name: "Uprising"}},
+			nil,
+			1,
+			1,
+		},
+		{
+			// the lookup is shared, the songs are stored as asked
+			"same normalized song",
+			[]model.SongDetail{{Group: "Muse", Name: "Uprising"}, {Group: " muse", Name: "UPRISING "}},
+			nil,
+			1,
+			2,
+		},
+		{
+			"not found remotely",
+			[]model.SongDetail{{Group: "Unknown", Name: "Nothing"}},
+			model.ErrNotFound,
+			1,
+			0,
+		},
+	}
+
+	const clients = 10
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			localRepo := newFakeLocalRepo()
+			remoteRepo := &countingRemoteRepo{delay: 50 * time.Millisecond}
+			s := New(localRepo, remoteRepo, config.Service{})
+
+			var (
+				wg    sync.WaitGroup
+				errs  = make([]error, clients)
+				songs = make([]model.SongDetail, clients)
+			)
+
+			for i := range clients {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					song, err := s.CreateSong(context.Background(), tt.songs[i%len(tt.songs)])
+					songs[i], errs[i] = song, err
+				}()
+			}
+
+			wg.Wait()
+
+			if got := remoteRepo.calls.Load(); got != tt.wantCalls {
+				t.Errorf("remote calls = %d, want %d", got, tt.wantCalls)
+			}
+
+			for i := range clients {
+				if !errors.Is(errs[i], tt.wantErr) {
+					t.Errorf("client %d: error = %v, want %v", i, errs[i], tt.wantErr)
+				}
+				if first := i % len(tt.songs); songs[i].ID != songs[first].ID {
+					t.Errorf("client %d: id = %d, want %d", i, songs[i].ID, songs[first].ID)
+				}
+				if want := tt.songs[i%len(tt.songs)]; errs[i] == nil && (songs[i].Group != want.Group || songs[i].Name != want.Name) {
+					t.Errorf("client %d: song = %q / %q, want %q / %q", i, songs[i].Group, songs[i].Name, want.Group, want.Name)
+				}
+			}
+
+			if got := localRepo.count(); got != tt.wantCount {
+				t.Errorf("songs count = %d, want %d", got, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestService_CreateSong_existing(t *testing.T) {
+
+	localRepo := newFakeLocalRepo(model.SongDetail{Group: "Muse", Name: "Uprising"})
+	remoteRepo := &countingRemoteRepo{}
+	s := New(localRepo, remoteRepo, config.Service{AdvisoryLock: true})
+
+	song, err := s.CreateSong(context.Background(), model.SongDetail{Group: "Muse", Name: "Uprising"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if song.ID != 1 {
+		t.Errorf("id = %d, want 1", song.ID)
+	}
+
+	if got := remoteRepo.calls.Load(); got != 0 {
+		t.Errorf("remote calls = %d, want 0", got)
+	}
+}
+
+func TestService_CreateSong_remoteOutsideTx(t *testing.T) {
+
+	localRepo := newFakeLocalRepo()
+	inTx := false
+
+	remoteRepo := remoteRepoFunc(func(ctx context.Context, song model.SongDetail) (model.SongDetail, error) {
+		inTx = localRepo.inTx.Load() > 0
+		return fakeRemoteRepo{}.GetSong(ctx, song)
+	})
+
+	for _, advisoryLock := range []bool{false, true} {
+
+		s := New(localRepo, remoteRepo, config.Service{AdvisoryLock: advisoryLock})
+
+		if _, err := s.CreateSong(context.Background(), model.SongDetail{Group: "Muse", Name: "Uprising"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if inTx {
+			t.Errorf("advisory lock %t: remote repo is called in transaction", advisoryLock)
+		}
+
+		localRepo.DeleteSong(context.Background(), 1)
+	}
+}
+
+func TestService_CreateSong_canceled(t *testing.T) {
+
+	remoteRepo := &countingRemoteRepo{delay: 200 * time.Millisecond}
+	s := New(newFakeLocalRepo(), remoteRepo, config.Service{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := s.CreateSong(ctx, model.SongDetail{Group: "Muse", Name: "Uprising"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestService_GetSongText(t *testing.T) {
+
+	localRepo := newFakeLocalRepo(model.SongDetail{Group: "Muse", Name: "Uprising", Text: "en 1\n\nen 2\n\nen 3"})
+	localRepo.lyrics = map[uint64][]model.SongLyrics{1: {
+		{SongID: 1, Lang: "en", Original: true, Text: "en 1\n\nen 2\n\nen 3"},
+		{SongID: 1, Lang: "ru", Translator: "someone", Text: "ru 1\n\nru 2"},
+		{SongID: 1, Lang: "pt-BR", Text: "pt 1\n\npt 2\n\npt 3\n\npt 4"},
+	}}
+
+	s := New(localRepo, fakeRemoteRepo{}, config.Service{})
+
+	uint64p := func(v uint64) *uint64 { return &v }
+
+	tests := []struct {
+		name    string
+		req     model.GetSongTextRequest
+		want    model.SongText
+		wantErr error
+	}{
+		{
+			"original by default",
+			model.GetSongTextRequest{ID: 1},
+			model.SongText{Lang: "en", Original: true, Verses: []string{"en 1", "en 2", "en 3"}},
+			nil,
+		},
+		{
+			"lang",
+			model.GetSongTextRequest{ID: 1, Lang: "ru"},
+			model.SongText{Lang: "ru", Translator: "someone", Verses: []string{"ru 1", "ru 2"}},
+			nil,
+		},
+		{
+			"lang by primary subtag",
+			model.GetSongTextRequest{ID: 1, Lang: "pt", Limit: uint64p(1)},
+			model.SongText{Lang: "pt-BR", Verses: []string{"pt 1"}},
+			nil,
+		},
+		{
+			"lang not found",
+			model.GetSongTextRequest{ID: 1, Lang: "de", AcceptLanguages: []string{"ru"}},
+			model.SongText{},
+			model.ErrNotFound,
+		},
+		{
+			"accept language",
+			model.GetSongTextRequest{ID: 1, AcceptLanguages: []string{"de", "ru-RU", "en"}},
+			model.SongText{Lang: "ru", Translator: "someone", Verses: []string{"ru 1", "ru 2"}},
+			nil,
+		},
+		{
+			"accept language falls back to the original",
+			model.GetSongTextRequest{ID: 1, AcceptLanguages: []string{"de", "fr"}},
+			model.SongText{Lang: "en", Original: true, Verses: []string{"en 1", "en 2", "en 3"}},
+			nil,
+		},
+		{
+			"side by side, translation is shorter",
+			model.GetSongTextRequest{ID: 1, Lang: "ru", SideBySide: true},
+			model.SongText{
+				Lang: "ru", Translator: "someone",
+				Verses:         []string{"ru 1", "ru 2", ""},
+				OriginalVerses: []string{"en 1", "en 2", "en 3"},
+			},
+			nil,
+		},
+		{
+			"side by side, translation is longer",
+			model.GetSongTextRequest{ID: 1, Lang: "pt-BR", SideBySide: true, Offset: uint64p(1)},
+			model.SongText{
+				Lang:           "pt-BR",
+				Verses:         []string{"pt 2", "pt 3", "pt 4"},
+				OriginalVerses: []string{"en 2", "en 3", ""},
+			},
+			nil,
+		},
+		{
+			"side by side of the original",
+			model.GetSongTextRequest{ID: 1, SideBySide: true},
+			model.SongText{Lang: "en", Original: true, Verses: []string{"en 1", "en 2", "en 3"}},
+			nil,
+		},
+		{
+			"song not found",
+			model.GetSongTextRequest{ID: 2},
+			model.SongText{},
+			model.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			got, err := s.GetSongText(context.Background(), tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("text = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_CreateSong_spelling(t *testing.T) {
+
+	localRepo := newFakeLocalRepo()
+	remoteRepo := &countingRemoteRepo{}
+	s := New(localRepo, remoteRepo, config.Service{})
+
+	for range 2 {
+		song, err := s.CreateSong(context.Background(), model.SongDetail{Group: "muse", Name: "uprising"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if song.Group != "muse" || song.Name != "uprising" {
+			t.Fatalf("song = %q / %q, want the spelling as asked", song.Group, song.Name)
+		}
+	}
+
+	// the second create finds the song stored with the same spelling
+	if got := remoteRepo.calls.Load(); got != 1 {
+		t.Errorf("remote calls = %d, want 1", got)
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"effective-mobile-go/internal/logger"
+	"effective-mobile-go/internal/model"
+	"effective-mobile-go/internal/tracing"
+)
+
+const loggerGroup = "service"
+
+// GetSongStats returns the song text statistics. The statistics is computed on the fly
+// (and stored) for songs which have not been analyzed yet.
+func (s Service) GetSongStats(ctx context.Context, songID uint64) (model.SongStats, error) {
+	ctx, span := tracing.Start(ctx, "Service.GetSongStats")
+	defer span.End()
+
+	stats, err := s.localRepo.GetSongStats(ctx, songID)
+	if !errors.Is(err, model.ErrNotFound) {
+		return stats, err
+	}
+
+	song, err := s.localRepo.GetSong(ctx, songID)
+	if err != nil {
+		return stats, err
+	}
+
+	stats = analyzeText(song.Text)
+	stats.SongID = song.ID
+
+	if err := s.localRepo.PutSongStats(ctx, stats); err != nil {
+		getLogger(ctx).Warn("can't store song stats", "songID", song.ID, "error", err)
+	}
+
+	return stats, nil
+}
+
+// ComputeSongStats analyzes the texts of the songs matching the filters which have no
+// statistics yet (all of them if all) and stores the results. The songs created before the
+// statistics was introduced have none, so they are missing from the lists filtered by the
+// language until then. The language of the original lyrics, if any, overrides the
+// detected one. Returns the number of the songs analyzed.
+func (s Service) ComputeSongStats(ctx context.Context, req model.SongFilters, all bool) (int, error) {
+	ctx, span := tracing.Start(ctx, "Service.ComputeSongStats")
+	defer span.End()
+
+	// the songs are collected first, so the cursor isn't held during the writes
+	var songs []model.SongDetail
+
+	err := s.localRepo.ExportSongs(ctx, req, true, func(song model.SongDetail) error {
+		songs = append(songs, song)
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+
+	for _, song := range songs {
+
+		if !all {
+			_, err := s.localRepo.GetSongStats(ctx, song.ID)
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, model.ErrNotFound) {
+				return n, err
+			}
+		}
+
+		lyrics, err := s.localRepo.ListSongLyrics(ctx, song.ID)
+		if err != nil {
+			return n, err
+		}
+
+		stats := analyzeText(song.Text)
+		stats.SongID = song.ID
+
+		for _, v := range lyrics {
+			if v.Original && v.Lang != "" {
+				stats.Lang = v.Lang
+			}
+		}
+
+		if err := s.localRepo.PutSongStats(ctx, stats); err != nil {
+			return n, err
+		}
+
+		n++
+	}
+
+	return n, nil
+}
+
+// updateStats analyzes the song text and stores the result. The lang, if not empty,
+// overrides the detected language. The statistics is secondary data, so errors are
+// logged only and do not fail the operation that changed the text.
+func (s Service) updateStats(ctx context.Context, songID uint64, text string, lang string) {
+
+	stats := analyzeText(text)
+	stats.SongID = songID
+
+	if lang != "" {
+		stats.Lang = lang
+	}
+
+	if err := s.localRepo.PutSongStats(ctx, stats); err != nil {
+		getLogger(ctx).Warn("can't store song stats", "songID", songID, "error", err)
+	}
+}
+
+func getLogger(ctx context.Context) *slog.Logger {
+	return logger.GetLoggerFromContextOrDefault(ctx).WithGroup(loggerGroup)
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"effective-mobile-go/internal/config"
+	"effective-mobile-go/internal/model"
+)
+
+func TestService_ComputeSongStats(t *testing.T) {
+
+	localRepo := newFakeLocalRepo(
+		model.SongDetail{Group: "Muse", Name: "Uprising", Text: "You set my soul alight"},
+		model.SongDetail{Group: "Кино", Name: "Кукушка", Text: "Я не я, и лошадь не моя"},
+		model.SongDetail{Group: "Muse", Name: "Madness", Text: "I can't get these memories out of my mind"},
+	)
+	localRepo.lyrics = map[uint64][]model.SongLyrics{3: {{SongID: 3, Lang: "en-GB", Original: true}}}
+	localRepo.stats[1] = model.SongStats{SongID: 1, Lang: "fr"} // analyzed already
+
+	s := New(localRepo, fakeRemoteRepo{}, config.Service{})
+
+	n, err := s.ComputeSongStats(context.Background(), model.SongFilters{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2 {
+		t.Errorf("analyzed = %d, want 2", n)
+	}
+
+	for id, lang := range map[uint64]string{1: "fr", 2: "ru", 3: "en-GB"} {
+		if got := localRepo.stats[id].Lang; got != lang {
+			t.Errorf("song %d: lang = %q, want %q", id, got, lang)
+		}
+	}
+
+	n, err = s.ComputeSongStats(context.Background(), model.SongFilters{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 3 || localRepo.stats[1].Lang != "en" {
+		t.Errorf("analyzed = %d, song 1 lang = %q, want 3 and en", n, localRepo.stats[1].Lang)
+	}
+}
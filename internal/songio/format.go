@@ -0,0 +1,86 @@
+package songio
+
+import (
+	"errors"
+	"mime"
+	"strings"
+)
+
+type Format string
+
+const (
+	CSV    Format = "csv"
+	JSON   Format = "json"
+	NDJSON Format = "ndjson"
+	XLSX   Format = "xlsx" // export only
+)
+
+var ErrUnknownFormat = errors.New("unknown format")
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case CSV, JSON, NDJSON, XLSX:
+		return f, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, see ParseFormat.
+func (f *Format) UnmarshalText(b []byte) error {
+	v, err := ParseFormat(string(b))
+	if err != nil {
+		return err
+	}
+	*f = v
+	return nil
+}
+
+// FormatFromContentType returns the format by the media type of the Content-Type header.
+func FormatFromContentType(contentType string) (Format, error) {
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", ErrUnknownFormat
+	}
+
+	switch mediaType {
+	case "text/csv":
+		return CSV, nil
+	case "application/json":
+		return JSON, nil
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return NDJSON, nil
+	}
+
+	return "", ErrUnknownFormat
+}
+
+// FormatFromFileName returns the format by the file extension.
+func FormatFromFileName(name string) (Format, error) {
+
+	i := strings.LastIndexByte(name, '.')
+	if i < 0 {
+		return "", ErrUnknownFormat
+	}
+
+	switch ext := strings.ToLower(name[i+1:]); ext {
+	case "jsonl":
+		return NDJSON, nil
+	default:
+		return ParseFormat(ext)
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case JSON:
+		return "application/json"
+	case NDJSON:
+		return "application/x-ndjson"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/octet-stream"
+}
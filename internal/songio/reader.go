@@ -0,0 +1,267 @@
+package songio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"effective-mobile-go/internal/model"
+)
+
+type ImportRow = model.ImportRow
+
+// Reader reads song rows one by one. Read returns io.EOF at the end of input and
+// *RowError if the row can't be parsed but the next rows still can be read. Any
+// other error is fatal: ErrMalformed if the input doesn't follow the format, otherwise
+// the error of the underlying reader.
+type Reader interface {
+	Read() (ImportRow, error)
+}
+
+// ErrMalformed matches the errors of the input which doesn't follow the format.
+var ErrMalformed = errors.New("malformed input")
+
+type malformedError struct {
+	error
+}
+
+func (e malformedError) Unwrap() error {
+	return e.error
+}
+
+func (e malformedError) Is(target error) bool {
+	return target == ErrMalformed
+}
+
+func malformed(err error) error {
+	return malformedError{err}
+}
+
+// jsonError marks the error of the JSON decoder as malformed unless it's the one of the
+// underlying reader.
+func jsonError(err error) error {
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || err == io.EOF || err == io.ErrUnexpectedEOF {
+		return malformed(fmt.Errorf("json: %w", err))
+	}
+
+	return fmt.Errorf("json: %w", err)
+}
+
+type RowError struct {
+	Row int
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+func NewReader(format Format, r io.Reader) (Reader, error) {
+	switch format {
+	case CSV:
+		return newCSVReader(r)
+	case JSON:
+		return newJSONReader(r)
+	case NDJSON:
+		return newNDJSONReader(r), nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+type record struct {
+	Group   string `json:"group"`
+	Song    string `json:"song"`
+	Release string `json:"release"`
+	Text    string `json:"text"`
+	Link    string `json:"link"`
+}
+
+func (rec record) row(n int) (ImportRow, error) {
+
+	row := ImportRow{
+		Row:   n,
+		Group: strings.TrimSpace(rec.Group),
+		Song:  strings.TrimSpace(rec.Song),
+		Text:  rec.Text,
+		Link:  strings.TrimSpace(rec.Link),
+	}
+
+	if s := strings.TrimSpace(rec.Release); s != "" {
+		v, err := model.ParseDate(s)
+		if err != nil {
+			return row, &RowError{n, errors.New("release must be DD.MM.YYYY")}
+		}
+		row.Release = v
+	}
+
+	return row, nil
+}
+
+// csvReader reads CSV with a header. The columns are matched by names, so their order
+// is arbitrary. The group and song columns are required.
+type csvReader struct {
+	r    *csv.Reader
+	cols map[string]int
+	n    int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		switch {
+		case err == io.EOF:
+			return nil, malformed(errors.New("csv: no header"))
+		case errors.As(err, &parseErr):
+			return nil, malformed(fmt.Errorf("csv: %w", err))
+		}
+		return nil, fmt.Errorf("csv: %w", err)
+	}
+
+	cols := map[string]int{}
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+
+	for _, name := range []string{"group", "song"} {
+		if _, ok := cols[name]; !ok {
+			return nil, malformed(fmt.Errorf("csv: no %s column", name))
+		}
+	}
+
+	return &csvReader{r: cr, cols: cols, n: 1}, nil
+}
+
+func (r *csvReader) Read() (ImportRow, error) {
+
+	fields, err := r.r.Read()
+	r.n++
+
+	if err != nil {
+		var parseErr *csv.ParseError
+		switch {
+		case errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount):
+			return ImportRow{Row: r.n}, &RowError{r.n, err}
+		case errors.As(err, &parseErr):
+			return ImportRow{}, malformed(err)
+		}
+		return ImportRow{}, err
+	}
+
+	get := func(name string) string {
+		if i, ok := r.cols[name]; ok && i < len(fields) {
+			return fields[i]
+		}
+		return ""
+	}
+
+	return record{
+		Group:   get("group"),
+		Song:    get("song"),
+		Release: get("release"),
+		Text:    get("text"),
+		Link:    get("link"),
+	}.row(r.n)
+}
+
+// jsonReader reads JSON array of objects without loading it into memory.
+type jsonReader struct {
+	d *json.Decoder
+	n int
+}
+
+func newJSONReader(r io.Reader) (*jsonReader, error) {
+
+	d := json.NewDecoder(r)
+
+	tok, err := d.Token()
+	if err != nil {
+		return nil, jsonError(err)
+	}
+
+	if tok != json.Delim('[') {
+		return nil, malformed(errors.New("json: array expected"))
+	}
+
+	return &jsonReader{d: d}, nil
+}
+
+func (r *jsonReader) Read() (ImportRow, error) {
+
+	if !r.d.More() {
+		if _, err := r.d.Token(); err != nil { // closing ]
+			return ImportRow{}, jsonError(err)
+		}
+		return ImportRow{}, io.EOF
+	}
+
+	r.n++
+
+	var rec record
+	if err := r.d.Decode(&rec); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return ImportRow{Row: r.n}, &RowError{r.n, err} // the value has been read, we can go on
+		}
+		return ImportRow{}, jsonError(err)
+	}
+
+	return rec.row(r.n)
+}
+
+// ndjsonReader reads newline delimited JSON objects. Empty lines are skipped.
+type ndjsonReader struct {
+	s *bufio.Scanner
+	n int
+}
+
+const maxLineSize = 1 << 20
+
+func newNDJSONReader(r io.Reader) *ndjsonReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, maxLineSize)
+	return &ndjsonReader{s: s}
+}
+
+func (r *ndjsonReader) Read() (ImportRow, error) {
+
+	for r.s.Scan() {
+		r.n++
+
+		line := r.s.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+
+		var rec record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return ImportRow{Row: r.n}, &RowError{r.n, err}
+		}
+
+		return rec.row(r.n)
+	}
+
+	if err := r.s.Err(); err != nil {
+		if err == bufio.ErrTooLong {
+			return ImportRow{}, malformed(fmt.Errorf("ndjson: line %d: %w", r.n+1, err))
+		}
+		return ImportRow{}, fmt.Errorf("ndjson: %w", err)
+	}
+
+	return ImportRow{}, io.EOF
+}
@@ -0,0 +1,161 @@
+package songio
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"effective-mobile-go/internal/model"
+)
+
+func TestReader(t *testing.T) {
+
+	release, _ := model.ParseDate("16.07.2006")
+
+	tests := []struct {
+		name    string
+		format  Format
+		input   string
+		want    []ImportRow
+		rowErrs []int // rows with *RowError
+	}{
+		{
+			"csv",
+			CSV,
+			"song,group,release\nSupermassive Black Hole,Muse,16.07.2006\n\"Uprising\", Muse ,\nHysteria,Muse,2003\n",
+			[]ImportRow{
+				{Row: 2, Group: "Muse", Song: "Supermassive Black Hole", Release: release},
+				{Row: 3, Group: "Muse", Song: "Uprising"},
+				{Row: 4, Group: "Muse", Song: "Hysteria"},
+			},
+			[]int{4},
+		},
+		{
+			"json",
+			JSON,
+			`[{"group":"Muse","song":"Supermassive Black Hole","release":"16.07.2006"},{"group":1},{"group":"Muse","song":"Uprising","link":"https://example.com"}]`,
+			[]ImportRow{
+				{Row: 1, Group: "Muse", Song: "Supermassive Black Hole", Release: release},
+				{Row: 2},
+				{Row: 3, Group: "Muse", Song: "Uprising", Link: "https://example.com"},
+			},
+			[]int{2},
+		},
+		{
+			"ndjson",
+			NDJSON,
+			"{\"group\":\"Muse\",\"song\":\"Uprising\",\"text\":\"They will not force us\"}\n\n{oops}\n{\"group\":\"Muse\",\"song\":\"Hysteria\"}",
+			[]ImportRow{
+				{Row: 1, Group: "Muse", Song: "Uprising", Text: "They will not force us"},
+				{Row: 3},
+				{Row: 4, Group: "Muse", Song: "Hysteria"},
+			},
+			[]int{3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r, err := NewReader(tt.format, strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var (
+				got     []ImportRow
+				rowErrs []int
+			)
+
+			for {
+				row, err := r.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					var rowErr *RowError
+					if !errors.As(err, &rowErr) {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					rowErrs = append(rowErrs, rowErr.Row)
+				}
+				got = append(got, row)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows = %+v, want %+v", got, tt.want)
+			}
+
+			if !reflect.DeepEqual(rowErrs, tt.rowErrs) {
+				t.Errorf("row errors = %v, want %v", rowErrs, tt.rowErrs)
+			}
+		})
+	}
+}
+
+func TestNewReader_error(t *testing.T) {
+	tests := []struct {
+		format Format
+		input  string
+	}{
+		{CSV, ""},
+		{CSV, "song,release\n"},
+		{JSON, "{}"},
+		{JSON, ""},
+		{"xml", "<songs/>"},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewReader(tt.format, strings.NewReader(tt.input)); err == nil {
+			t.Errorf("NewReader(%s, %q): error expected", tt.format, tt.input)
+		}
+	}
+}
+
+func TestReader_fatal(t *testing.T) {
+
+	errRead := errors.New("connection reset")
+
+	tests := []struct {
+		name    string
+		format  Format
+		input   io.Reader
+		wantErr error
+	}{
+		{"csv malformed", CSV, strings.NewReader("group,song\nMuse,\"Uprising\"x\n"), ErrMalformed},
+		{"csv no column", CSV, strings.NewReader("group\nMuse\n"), ErrMalformed},
+		{"json malformed", JSON, strings.NewReader(`[{"group":"Muse"},}`), ErrMalformed},
+		{"json truncated", JSON, strings.NewReader(`[{"group":"Muse"}`), ErrMalformed},
+		{"ndjson too long", NDJSON, strings.NewReader(strings.Repeat("x", maxLineSize+1)), ErrMalformed},
+		{"csv read error", CSV, io.MultiReader(strings.NewReader("group,song\nMuse,Uprising\n"), iotest.ErrReader(errRead)), errRead},
+		{"json read error", JSON, io.MultiReader(strings.NewReader(`[{"group":"Muse"},`), iotest.ErrReader(errRead)), errRead},
+		{"ndjson read error", NDJSON, io.MultiReader(strings.NewReader("{}\n"), iotest.ErrReader(errRead)), errRead},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r, err := NewReader(tt.format, tt.input)
+
+			for err == nil {
+				_, err = r.Read()
+			}
+
+			var rowErr *RowError
+			if errors.As(err, &rowErr) {
+				t.Fatalf("error = %v, want fatal", err)
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != ErrMalformed && errors.Is(err, ErrMalformed) {
+				t.Fatalf("error = %v, must not be malformed", err)
+			}
+		})
+	}
+}
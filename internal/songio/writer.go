@@ -0,0 +1,161 @@
+package songio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"effective-mobile-go/internal/model"
+)
+
+type SongDetail = model.SongDetail
+
+// Writer writes songs one by one without buffering them all. Close finishes the output
+// (closing brackets, archive directory, etc.) but does not close the underlying writer.
+type Writer interface {
+	Write(SongDetail) error
+	Close() error
+}
+
+// NewWriter returns the writer of the format. The columns are id, group, song, release,
+// link and, if withText, text. The names match the import columns, so the export result
+// can be imported back.
+func NewWriter(format Format, w io.Writer, withText bool) (Writer, error) {
+	switch format {
+	case CSV:
+		return newCSVWriter(w, withText)
+	case JSON:
+		return &jsonWriter{w: bufio.NewWriter(w), withText: withText}, nil
+	case NDJSON:
+		bw := bufio.NewWriter(w)
+		return &ndjsonWriter{w: bw, enc: json.NewEncoder(bw), withText: withText}, nil
+	case XLSX:
+		return newXLSXWriter(w, withText)
+	}
+	return nil, ErrUnknownFormat
+}
+
+func columns(withText bool) []string {
+	cols := []string{"id", "group", "song", "release", "link"}
+	if withText {
+		cols = append(cols, "text")
+	}
+	return cols
+}
+
+func fields(song SongDetail, withText bool) []string {
+	v := []string{
+		strconv.FormatUint(song.ID, 10),
+		song.Group,
+		song.Name,
+		song.Release.String(),
+		song.Link,
+	}
+	if withText {
+		v = append(v, song.Text)
+	}
+	return v
+}
+
+type exportRecord struct {
+	ID      uint64 `json:"id"`
+	Group   string `json:"group"`
+	Song    string `json:"song"`
+	Release string `json:"release,omitempty"`
+	Link    string `json:"link,omitempty"`
+	Text    string `json:"text,omitempty"`
+}
+
+func newExportRecord(song SongDetail, withText bool) exportRecord {
+	rec := exportRecord{
+		ID:      song.ID,
+		Group:   song.Group,
+		Song:    song.Name,
+		Release: song.Release.String(),
+		Link:    song.Link,
+	}
+	if withText {
+		rec.Text = song.Text
+	}
+	return rec
+}
+
+type csvWriter struct {
+	w        *csv.Writer
+	withText bool
+}
+
+func newCSVWriter(w io.Writer, withText bool) (*csvWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns(withText)); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: cw, withText: withText}, nil
+}
+
+func (w *csvWriter) Write(song SongDetail) error {
+	return w.w.Write(fields(song, w.withText))
+}
+
+func (w *csvWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// jsonWriter writes JSON array element by element.
+type jsonWriter struct {
+	w        *bufio.Writer
+	n        int
+	withText bool
+}
+
+func (w *jsonWriter) Write(song SongDetail) error {
+
+	b, err := json.Marshal(newExportRecord(song, w.withText))
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n"
+	if w.n == 0 {
+		sep = "[\n"
+	}
+	w.n++
+
+	if _, err := w.w.WriteString(sep); err != nil {
+		return err
+	}
+
+	_, err = w.w.Write(b)
+	return err
+}
+
+func (w *jsonWriter) Close() error {
+
+	end := "\n]\n"
+	if w.n == 0 {
+		end = "[]\n"
+	}
+
+	if _, err := w.w.WriteString(end); err != nil {
+		return err
+	}
+
+	return w.w.Flush()
+}
+
+type ndjsonWriter struct {
+	w        *bufio.Writer
+	enc      *json.Encoder
+	withText bool
+}
+
+func (w *ndjsonWriter) Write(song SongDetail) error {
+	return w.enc.Encode(newExportRecord(song, w.withText))
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.w.Flush()
+}
@@ -0,0 +1,118 @@
+package songio
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"effective-mobile-go/internal/model"
+)
+
+func TestWriter_roundTrip(t *testing.T) {
+
+	release, _ := model.ParseDate("16.07.2006")
+
+	songs := []SongDetail{
+		{ID: 1, Group: "Muse", Name: "Supermassive Black Hole", Release: release, Link: "https://example.com/1", Text: "Ooh baby,\n\"don't\" you know"},
+		{ID: 2, Group: "Muse", Name: "Uprising"},
+	}
+
+	want := []ImportRow{
+		{Row: 0, Group: "Muse", Song: "Supermassive Black Hole", Release: release, Link: "https://example.com/1", Text: "Ooh baby,\n\"don't\" you know"},
+		{Row: 0, Group: "Muse", Song: "Uprising"},
+	}
+
+	for _, format := range []Format{CSV, JSON, NDJSON} {
+		t.Run(string(format), func(t *testing.T) {
+
+			var buf bytes.Buffer
+
+			w, err := NewWriter(format, &buf, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, song := range songs {
+				if err := w.Write(song); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := NewReader(format, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []ImportRow
+			for {
+				row, err := r.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				row.Row = 0
+				got = append(got, row)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestWriter_emptyJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, _ := NewWriter(JSON, &buf, false)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := buf.String(), "[]\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestWriter_xlsx(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(XLSX, &buf, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(SongDetail{ID: 1, Group: "AC/DC", Name: "Rock & Roll <Train>"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sheet string
+	for _, f := range zr.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, _ := f.Open()
+			b, _ := io.ReadAll(rc)
+			rc.Close()
+			sheet = string(b)
+		}
+	}
+
+	for _, want := range []string{`<row r="1">`, `<row r="2">`, `Rock &amp; Roll &lt;Train&gt;`, `</sheetData></worksheet>`} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet does not contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package songio
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+)
+
+// xlsxWriter writes the minimal Office Open XML workbook with the only sheet. The sheet
+// is the last archive entry and is streamed, the strings are inline (no shared strings
+// table), so nothing is kept in memory.
+type xlsxWriter struct {
+	zw       *zip.Writer
+	w        *bufio.Writer
+	row      int
+	withText bool
+}
+
+var xlsxParts = [][2]string{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="songs" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`},
+}
+
+func newXLSXWriter(w io.Writer, withText bool) (*xlsxWriter, error) {
+
+	zw := zip.NewWriter(w)
+
+	for _, part := range xlsxParts {
+		f, err := zw.Create(part[0])
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	x := &xlsxWriter{zw: zw, w: bufio.NewWriter(f), withText: withText}
+
+	x.w.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	x.w.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	if err := x.writeRow(columns(withText)); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
+
+func (x *xlsxWriter) Write(song SongDetail) error {
+	return x.writeRow(fields(song, x.withText))
+}
+
+func (x *xlsxWriter) writeRow(values []string) error {
+	x.row++
+
+	x.w.WriteString(`<row r="` + strconv.Itoa(x.row) + `">`)
+
+	for _, v := range values {
+		x.w.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+		if err := xml.EscapeText(x.w, []byte(v)); err != nil {
+			return err
+		}
+		x.w.WriteString(`</t></is></c>`)
+	}
+
+	_, err := x.w.WriteString(`</row>`)
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+
+	x.w.WriteString(`</sheetData></worksheet>`)
+
+	if err := x.w.Flush(); err != nil {
+		return err
+	}
+
+	return x.zw.Close()
+}
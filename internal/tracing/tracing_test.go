@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"effective-mobile-go/internal/config"
+)
+
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	if _, err := Setup(context.Background(), config.Tracing{Exporter: "none"}); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+
+	return rec
+}
+
+func TestRoute(t *testing.T) {
+
+	rec := setupRecorder(t)
+
+	h := otelhttp.NewHandler(Route("GET    /songs/{id}", http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})), "http.server")
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/songs/1", nil))
+
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("spans = %d, want 1", len(spans))
+	}
+
+	if got, want := spans[0].Name(), "GET /songs/{id}"; got != want {
+		t.Errorf("span name = %q, want %q", got, want)
+	}
+}
+
+func TestRemotePropagation(t *testing.T) {
+
+	rec := setupRecorder(t)
+
+	var traceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+
+	ctx, span := Start(context.Background(), "Service.CreateSong")
+
+	client := http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+	req, _ := http.NewRequestWithContext(ctx, "GET", upstream.URL, nil)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	span.End()
+
+	traceID := span.SpanContext().TraceID().String()
+	if !strings.HasPrefix(traceparent, "00-"+traceID+"-") {
+		t.Errorf("traceparent = %q, want trace %s", traceparent, traceID)
+	}
+
+	if got := len(rec.Ended()); got != 2 {
+		t.Errorf("spans = %d, want 2 (service and client)", got)
+	}
+}
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE song_lyrics (
+    song_id BIGINT NOT NULL REFERENCES song ON DELETE CASCADE,
+    lang VARCHAR(35) NOT NULL,
+    original BOOLEAN NOT NULL DEFAULT FALSE,
+    translator VARCHAR(256) NOT NULL DEFAULT '',
+    text TEXT NOT NULL,
+    PRIMARY KEY (song_id, lang),
+    -- only one original per song (deferred to allow swapping in a single statement)
+    EXCLUDE (song_id WITH =) WHERE (original) DEFERRABLE INITIALLY DEFERRED
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE song_lyrics;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- the texts are analyzed in the app, so the stats of the existing songs are computed by `app stats`
+-- +goose StatementBegin
+CREATE TABLE song_stats (
+    song_id BIGINT PRIMARY KEY REFERENCES song ON DELETE CASCADE,
+    verses INT NOT NULL,
+    lines INT NOT NULL,
+    words INT NOT NULL,
+    unique_words INT NOT NULL,
+    unique_ratio REAL NOT NULL,
+    lang VARCHAR(35) NOT NULL,
+    reading_time INT NOT NULL, -- seconds
+    word_freq JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX song_stats_lang_idx ON song_stats (lang);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE song_stats;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE UNLOGGED TABLE rate_limit (
+    key TEXT PRIMARY KEY,
+    tat TIMESTAMPTZ NOT NULL -- when the bucket is full again
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE rate_limit;
+-- +goose StatementEnd
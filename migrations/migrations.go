@@ -0,0 +1,8 @@
+// Package migrations embeds the goose SQL migrations, so the binary doesn't depend on
+// the working directory.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS